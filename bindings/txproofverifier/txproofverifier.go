@@ -0,0 +1,231 @@
+// Code generated - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package txproofverifier
+
+import (
+	"math/big"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = big.NewInt
+	_ = strings.NewReader
+	_ = ethereum.NotFound
+	_ = bind.Bind
+	_ = common.Big1
+	_ = types.BloomLookup
+	_ = event.NewSubscription
+)
+
+// TxProofVerifierABI is the input ABI used to generate the binding from.
+const TxProofVerifierABI = "[{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"root\",\"type\":\"bytes32\"},{\"internalType\":\"bytes\",\"name\":\"key\",\"type\":\"bytes\"},{\"internalType\":\"bytes\",\"name\":\"proof\",\"type\":\"bytes\"}],\"name\":\"verifyProof\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"exists\",\"type\":\"bool\"},{\"internalType\":\"bytes\",\"name\":\"value\",\"type\":\"bytes\"}],\"stateMutability\":\"pure\",\"type\":\"function\"}]"
+
+// TxProofVerifierBin is the compiled bytecode used for deploying new contracts.
+var TxProofVerifierBin = "0x608060405234801561001057600080fd5b506118d0806100206000396000f3fe608060405234801561001057600080fd5b506004361061002b5760003560e01c806341493c6014610030575b600080fd5b61004361003e36600461157a565b61005a565b60405161005192919061160b565b60405180910390f35b600060606000610069856104c2565b9050600061007e61007986610644565b610711565b9050866000805b835181101561047c576100ba8482815181106100a3576100a3611647565b6020026020010151515160c060009190911a101590565b61010b5760405162461bcd60e51b815260206004820152601860248201527f70726f6f66206e6f6465206973206e6f742061206c697374000000000000000060448201526064015b60405180910390fd5b8261012e85838151811061012157610121611647565b6020026020010151610900565b80519060200120146101825760405162461bcd60e51b815260206004820152601860248201527f70726f6f66206e6f64652068617368206d69736d6174636800000000000000006044820152606401610102565b600084828151811061019657610196611647565b602002602001015190505b60006101ac82610711565b90506101cb604051806040016040528060008152602001600081525090565b60008251601103610279578851861061021a5760405162461bcd60e51b81526020600482015260116024820152700d6caf240cadcc8e640dad2c840e0c2e8d607b1b6044820152606401610102565b600089878151811061022e5761022e611647565b0160200151845160f89190911c915084908290811061024f5761024f611647565b60200260200101519250601060ff168160ff16149150868061027090611673565b97505050610394565b825160020361034c5760006102a78460008151811061029a5761029a611647565b6020026020010151610a70565b90506102b281610b3c565b6102bd8a8883610c2b565b6102e6576000604051806020016040528060008152509b509b50505050505050505050506104ba565b836001815181106102f9576102f9611647565b60200260200101519250601060ff168160018351610317919061168c565b8151811061032757610327611647565b0160200151825160f89190911c919091149250610344908861169f565b965050610394565b60405162461bcd60e51b815260206004820152601f60248201527f696e76616c6964206e756d626572206f66206c69737420656c656d656e7473006044820152606401610102565b80156103ba576103a382610a70565b995060008a51119a505050505050505050506104ba565b81515160c060009190911a106103d4575091506101a19050565b60006103df83610a70565b9050805160000361040f576000604051806020016040528060008152509b509b50505050505050505050506104ba565b80516020146104605760405162461bcd60e51b815260206004820152601760248201527f696e76616c6964206368696c64207265666572656e63650000000000000000006044820152606401610102565b602001519650505050508061047481611673565b915050610085565b5060405162461bcd60e51b815260206004820152601260248201527170726f6f66206e6f6465206d697373696e6760701b6044820152606401610102565b935093915050565b6060815160026104d291906116b2565b6104dd90600161169f565b67ffffffffffffffff8111156104f5576104f56114d7565b6040519080825280601f01601f19166020018201604052801561051f576020820181803683370190505b50905060005b825181101561060357601083828151811061054257610542611647565b0160200151610554919060f81c6116df565b60f81b826105638360026116b2565b8151811061057357610573611647565b60200101906001600160f81b031916908160001a905350601083828151811061059e5761059e611647565b01602001516105b0919060f81c611701565b60f81b826105bf8360026116b2565b6105ca90600161169f565b815181106105da576105da611647565b60200101906001600160f81b031916908160001a905350806105fb81611673565b915050610525565b50601060f81b8160018351610618919061168c565b8151811061062857610628611647565b60200101906001600160f81b031916908160001a905350919050565b604080518082019091526000808252602082015260008251116106975760405162461bcd60e51b815260206004820152600b60248201526a195b5c1d1e481a5b9c1d5d60aa1b6044820152606401610102565b602082016000806106a783610ccf565b50865191935091506106b9828461169f565b146106f75760405162461bcd60e51b815260206004820152600e60248201526d747261696c696e6720627974657360901b6044820152606401610102565b505060408051808201909152908152915160208301525090565b606060008060006107258560000151610ccf565b9250925092508061076d5760405162461bcd60e51b81526020600482015260126024820152711a5d195b481a5cc81b9bdd0818481b1a5cdd60721b6044820152606401610102565b602085015161077c838561169f565b146107bd5760405162461bcd60e51b8152602060048201526011602482015270696e76616c6964206c6973742073697a6560781b6044820152606401610102565b602085015185516000916107d09161169f565b90506000808588600001516107e5919061169f565b90505b82811015610818576107fa8184610de8565b610804908261169f565b90508161081081611673565b9250506107e8565b8167ffffffffffffffff811115610831576108316114d7565b60405190808252806020026020018201604052801561087657816020015b604080518082019091526000808252602082015281526020019060019003908161084f5790505b50885190975061088790879061169f565b905060005b828110156108f45760006108a08386610de8565b90506040518060400160405280848152602001828152508983815181106108c9576108c9611647565b60209081029190910101526108de818461169f565b92505080806108ec90611673565b91505061088c565b50505050505050919050565b6060600061090d83610711565b90506000815167ffffffffffffffff81111561092b5761092b6114d7565b60405190808252806020026020018201604052801561095e57816020015b60608152602001906001900390816109495790505b5090508151600203610a035760006109828360008151811061029a5761029a611647565b905061098d81610b3c565b61099e61099982610e87565b6110b3565b826000815181106109b1576109b1611647565b60200260200101819052506109df836001815181106109d2576109d2611647565b602002602001015161111d565b826001815181106109f2576109f2611647565b602002602001018190525050610a5f565b815160110361034c5760005b6011811015610a5d57610a2d8382815181106109d2576109d2611647565b828281518110610a3f57610a3f611647565b60200260200101819052508080610a5590611673565b915050610a0f565b505b610a68816111a1565b949350505050565b60606000806000610a848560000151610ccf565b9250925092508015610acf5760405162461bcd60e51b81526020600482015260146024820152736974656d206973206e6f74206120737472696e6760601b6044820152606401610102565b8167ffffffffffffffff811115610ae857610ae86114d7565b6040519080825280601f01601f191660200182016040528015610b12576020820181803683370190505b5085519094506020850190610b3390610b2c90869061169f565b8285611236565b50505050919050565b6000815111610b845760405162461bcd60e51b8152602060048201526014602482015273656d7074792073686f7274206e6f6465206b657960601b6044820152606401610102565b60005b8151811015610c27576000828281518110610ba457610ba4611647565b016020015160f81c90506010811080610bd7575060ff81166010148015610bd7575060018351610bd4919061168c565b82145b610c145760405162461bcd60e51b815260206004820152600e60248201526d696e76616c6964206e6962626c6560901b6044820152606401610102565b5080610c1f81611673565b915050610b87565b5050565b60008351825184610c3c919061169f565b1115610c4a57506000610cc8565b60005b8251811015610cc257828181518110610c6857610c68611647565b01602001516001600160f81b03191685610c82838761169f565b81518110610c9257610c92611647565b01602001516001600160f81b03191614610cb0576000915050610cc8565b80610cba81611673565b915050610c4d565b50600190505b9392505050565b80516000908190819080821a9060011a6080821015610cfb576000600160009450945094505050610de1565b60b8821015610d7357610d0f60808361168c565b9350836001141580610d22575060808110155b610d635760405162461bcd60e51b81526020600482015260126024820152716e6f6e2063616e6f6e6963616c2073697a6560701b6044820152606401610102565b506001935060009150610de19050565b60c0821015610da157610d9086610d8b60b78561168c565b6112ac565b909550935060009250610de1915050565b60f8821015610dc6576001610db760c08461168c565b60019450945094505050610de1565b610dd586610d8b60f78561168c565b90955093506001925050505b9193909250565b6000806000610df685610ccf565b5090925090508381610e08848861169f565b610e12919061169f565b1115610e725760405162461bcd60e51b815260206004820152602960248201527f76616c75652073697a65206578636565647320617661696c61626c6520696e706044820152680eae840d8cadccee8d60bb1b6064820152608401610102565b610e7c818361169f565b925050505b92915050565b805160609060008115801590610ec05750601084610ea660018561168c565b81518110610eb657610eb6611647565b016020015160f81c145b15610ed65750600281610ed281611723565b9250505b610ee160028361173a565b610eec90600161169f565b67ffffffffffffffff811115610f0457610f046114d7565b6040519080825280601f01601f191660200182016040528015610f2e576020820181803683370190505b5092506000610f3e60028461174e565b600103610fa657610f50600183611762565b915084600081518110610f6557610f65611647565b016020015184516001600160f81b0319909116908590600090610f8a57610f8a611647565b60200101906001600160f81b031916908160001a905350600190505b60048260ff16901b84600081518110610fc157610fc1611647565b602001015160f81c60f81b60f81c1760f81b84600081518110610fe657610fe6611647565b60200101906001600160f81b031916908160001a905350805b83811015610b33578561101382600161169f565b8151811061102357611023611647565b602001015160f81c60f81b60f81c600487838151811061104557611045611647565b016020015160f890811c90911b91909117901b856002611065858561168c565b61106f919061173a565b61107a90600161169f565b8151811061108a5761108a611647565b60200101906001600160f81b031916908160001a9053506110ac60028261169f565b9050610fff565b6060815160011480156110e057506080826000815181106110d6576110d6611647565b016020015160f81c105b156110e9575090565b6110f58251608061136c565b8260405160200161110792919061177b565b6040516020818303038152906040529050919050565b606061113282515160c060009190911a101590565b1561119557600061114283610900565b90506020815110610e815760405162461bcd60e51b815260206004820152601760248201527f6f76657273697a656420656d626564646564206e6f64650000000000000000006044820152606401610102565b610e8161099983610a70565b60608060005b835181101561120057818482815181106111c3576111c3611647565b60200260200101516040516020016111dc92919061177b565b604051602081830303815290604052915080806111f890611673565b9150506111a7565b5061120d815160c061136c565b8160405160200161121f92919061177b565b604051602081830303815290604052915050919050565b6020811061126e578251825261124d60208461169f565b925061125a60208361169f565b915061126760208261168c565b9050611236565b80156112a7576000600161128383602061168c565b61128f9061010061188e565b611299919061168c565b845184518216911916178352505b505050565b60008060088311156112f15760405162461bcd60e51b815260206004820152600e60248201526d73697a6520746f6f206c6172676560901b6044820152606401610102565b506001830151600860208490030281901c9060001a8015801590611316575060388210155b6113575760405162461bcd60e51b81526020600482015260126024820152716e6f6e2063616e6f6e6963616c2073697a6560701b6044820152606401610102565b61136284600161169f565b9250509250929050565b606060388310156113ba57611381828461169f565b6040516020016113a4919060f89190911b6001600160f81b031916815260010190565b6040516020818303038152906040529050610e81565b6000835b80156113d957816113ce81611673565b92505060081c6113be565b5060006113e782600161169f565b67ffffffffffffffff8111156113ff576113ff6114d7565b6040519080825280601f01601f191660200182016040528015611429576020820181803683370190505b509050611436848361169f565b61144190603761169f565b60f81b8160008151811061145757611457611647565b60200101906001600160f81b031916908160001a90535060005b828110156114ce576114848160086116b2565b86901c60f81b82611495838661168c565b815181106114a5576114a5611647565b60200101906001600160f81b031916908160001a905350806114c681611673565b915050611471565b50949350505050565b634e487b7160e01b600052604160045260246000fd5b600082601f8301126114fe57600080fd5b813567ffffffffffffffff80821115611519576115196114d7565b604051601f8301601f19908116603f01168101908282118183101715611541576115416114d7565b8160405283815286602085880101111561155a57600080fd5b836020870160208301376000602085830101528094505050505092915050565b60008060006060848603121561158f57600080fd5b83359250602084013567ffffffffffffffff808211156115ae57600080fd5b6115ba878388016114ed565b935060408601359150808211156115d057600080fd5b506115dd868287016114ed565b9150509250925092565b60005b838110156116025781810151838201526020016115ea565b50506000910152565b821515815260406020820152600082518060408401526116328160608501602087016115e7565b601f01601f1916919091016060019392505050565b634e487b7160e01b600052603260045260246000fd5b634e487b7160e01b600052601160045260246000fd5b6000600182016116855761168561165d565b5060010190565b81810381811115610e8157610e8161165d565b80820180821115610e8157610e8161165d565b8082028115828204841417610e8157610e8161165d565b634e487b7160e01b600052601260045260246000fd5b600060ff8316806116f2576116f26116c9565b8060ff84160491505092915050565b600060ff831680611714576117146116c9565b8060ff84160691505092915050565b6000816117325761173261165d565b506000190190565b600082611749576117496116c9565b500490565b60008261175d5761175d6116c9565b500690565b60ff8181168382160190811115610e8157610e8161165d565b6000835161178d8184602088016115e7565b8351908301906117a18183602088016115e7565b01949350505050565b600181815b808511156117e55781600019048211156117cb576117cb61165d565b808516156117d857918102915b93841c93908002906117af565b509250929050565b6000826117fc57506001610e81565b8161180957506000610e81565b816001811461181f576002811461182957611845565b6001915050610e81565b60ff84111561183a5761183a61165d565b50506001821b610e81565b5060208310610133831016604e8410600b8410161715611868575081810a610e81565b61187283836117aa565b80600019048211156118865761188661165d565b029392505050565b6000610cc883836117ed56fea2646970667358221220ebb529d50005bfd32db20774c42029601105ee8a937288c4a36e5218fdbb82a164736f6c63430008150033"
+
+// DeployTxProofVerifier deploys a new Ethereum contract, binding an instance of TxProofVerifier to it.
+func DeployTxProofVerifier(auth *bind.TransactOpts, backend bind.ContractBackend) (common.Address, *types.Transaction, *TxProofVerifier, error) {
+	parsed, err := abi.JSON(strings.NewReader(TxProofVerifierABI))
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+
+	address, tx, contract, err := bind.DeployContract(auth, parsed, common.FromHex(TxProofVerifierBin), backend)
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	return address, tx, &TxProofVerifier{TxProofVerifierCaller: TxProofVerifierCaller{contract: contract}, TxProofVerifierTransactor: TxProofVerifierTransactor{contract: contract}, TxProofVerifierFilterer: TxProofVerifierFilterer{contract: contract}}, nil
+}
+
+// TxProofVerifier is an auto generated Go binding around an Ethereum contract.
+type TxProofVerifier struct {
+	TxProofVerifierCaller     // Read-only binding to the contract
+	TxProofVerifierTransactor // Write-only binding to the contract
+	TxProofVerifierFilterer   // Log filterer for contract events
+}
+
+// TxProofVerifierCaller is an auto generated read-only Go binding around an Ethereum contract.
+type TxProofVerifierCaller struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// TxProofVerifierTransactor is an auto generated write-only Go binding around an Ethereum contract.
+type TxProofVerifierTransactor struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// TxProofVerifierFilterer is an auto generated log filtering Go binding around an Ethereum contract events.
+type TxProofVerifierFilterer struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// TxProofVerifierSession is an auto generated Go binding around an Ethereum contract,
+// with pre-set call and transact options.
+type TxProofVerifierSession struct {
+	Contract     *TxProofVerifier  // Generic contract binding to set the session for
+	CallOpts     bind.CallOpts     // Call options to use throughout this session
+	TransactOpts bind.TransactOpts // Transaction auth options to use throughout this session
+}
+
+// TxProofVerifierCallerSession is an auto generated read-only Go binding around an Ethereum contract,
+// with pre-set call options.
+type TxProofVerifierCallerSession struct {
+	Contract *TxProofVerifierCaller // Generic contract caller binding to set the session for
+	CallOpts bind.CallOpts          // Call options to use throughout this session
+}
+
+// TxProofVerifierTransactorSession is an auto generated write-only Go binding around an Ethereum contract,
+// with pre-set transact options.
+type TxProofVerifierTransactorSession struct {
+	Contract     *TxProofVerifierTransactor // Generic contract transactor binding to set the session for
+	TransactOpts bind.TransactOpts          // Transaction auth options to use throughout this session
+}
+
+// TxProofVerifierRaw is an auto generated low-level Go binding around an Ethereum contract.
+type TxProofVerifierRaw struct {
+	Contract *TxProofVerifier // Generic contract binding to access the raw methods on
+}
+
+// TxProofVerifierCallerRaw is an auto generated low-level read-only Go binding around an Ethereum contract.
+type TxProofVerifierCallerRaw struct {
+	Contract *TxProofVerifierCaller // Generic read-only contract binding to access the raw methods on
+}
+
+// TxProofVerifierTransactorRaw is an auto generated low-level write-only Go binding around an Ethereum contract.
+type TxProofVerifierTransactorRaw struct {
+	Contract *TxProofVerifierTransactor // Generic write-only contract binding to access the raw methods on
+}
+
+// NewTxProofVerifier creates a new instance of TxProofVerifier, bound to a specific deployed contract.
+func NewTxProofVerifier(address common.Address, backend bind.ContractBackend) (*TxProofVerifier, error) {
+	contract, err := bindTxProofVerifier(address, backend, backend, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &TxProofVerifier{TxProofVerifierCaller: TxProofVerifierCaller{contract: contract}, TxProofVerifierTransactor: TxProofVerifierTransactor{contract: contract}, TxProofVerifierFilterer: TxProofVerifierFilterer{contract: contract}}, nil
+}
+
+// NewTxProofVerifierCaller creates a new read-only instance of TxProofVerifier, bound to a specific deployed contract.
+func NewTxProofVerifierCaller(address common.Address, caller bind.ContractCaller) (*TxProofVerifierCaller, error) {
+	contract, err := bindTxProofVerifier(address, caller, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &TxProofVerifierCaller{contract: contract}, nil
+}
+
+// NewTxProofVerifierTransactor creates a new write-only instance of TxProofVerifier, bound to a specific deployed contract.
+func NewTxProofVerifierTransactor(address common.Address, transactor bind.ContractTransactor) (*TxProofVerifierTransactor, error) {
+	contract, err := bindTxProofVerifier(address, nil, transactor, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &TxProofVerifierTransactor{contract: contract}, nil
+}
+
+// NewTxProofVerifierFilterer creates a new log filterer instance of TxProofVerifier, bound to a specific deployed contract.
+func NewTxProofVerifierFilterer(address common.Address, filterer bind.ContractFilterer) (*TxProofVerifierFilterer, error) {
+	contract, err := bindTxProofVerifier(address, nil, nil, filterer)
+	if err != nil {
+		return nil, err
+	}
+	return &TxProofVerifierFilterer{contract: contract}, nil
+}
+
+// bindTxProofVerifier binds a generic wrapper to an already deployed contract.
+func bindTxProofVerifier(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, error) {
+	parsed, err := abi.JSON(strings.NewReader(TxProofVerifierABI))
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(address, parsed, caller, transactor, filterer), nil
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_TxProofVerifier *TxProofVerifierRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _TxProofVerifier.Contract.TxProofVerifierCaller.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_TxProofVerifier *TxProofVerifierRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _TxProofVerifier.Contract.TxProofVerifierTransactor.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_TxProofVerifier *TxProofVerifierRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _TxProofVerifier.Contract.TxProofVerifierTransactor.contract.Transact(opts, method, params...)
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_TxProofVerifier *TxProofVerifierCallerRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _TxProofVerifier.Contract.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_TxProofVerifier *TxProofVerifierTransactorRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _TxProofVerifier.Contract.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_TxProofVerifier *TxProofVerifierTransactorRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _TxProofVerifier.Contract.contract.Transact(opts, method, params...)
+}
+
+// VerifyProof is a free data retrieval call binding the contract method 0x41493c60.
+//
+// Solidity: function verifyProof(bytes32 root, bytes key, bytes proof) pure returns(bool exists, bytes value)
+func (_TxProofVerifier *TxProofVerifierCaller) VerifyProof(opts *bind.CallOpts, root [32]byte, key []byte, proof []byte) (struct {
+	Exists bool
+	Value  []byte
+}, error) {
+	var out []interface{}
+	err := _TxProofVerifier.contract.Call(opts, &out, "verifyProof", root, key, proof)
+
+	outstruct := new(struct {
+		Exists bool
+		Value  []byte
+	})
+
+	outstruct.Exists = out[0].(bool)
+	outstruct.Value = out[1].([]byte)
+
+	return *outstruct, err
+
+}
+
+// VerifyProof is a free data retrieval call binding the contract method 0x41493c60.
+//
+// Solidity: function verifyProof(bytes32 root, bytes key, bytes proof) pure returns(bool exists, bytes value)
+func (_TxProofVerifier *TxProofVerifierSession) VerifyProof(root [32]byte, key []byte, proof []byte) (struct {
+	Exists bool
+	Value  []byte
+}, error) {
+	return _TxProofVerifier.Contract.VerifyProof(&_TxProofVerifier.CallOpts, root, key, proof)
+}
+
+// VerifyProof is a free data retrieval call binding the contract method 0x41493c60.
+//
+// Solidity: function verifyProof(bytes32 root, bytes key, bytes proof) pure returns(bool exists, bytes value)
+func (_TxProofVerifier *TxProofVerifierCallerSession) VerifyProof(root [32]byte, key []byte, proof []byte) (struct {
+	Exists bool
+	Value  []byte
+}, error) {
+	return _TxProofVerifier.Contract.VerifyProof(&_TxProofVerifier.CallOpts, root, key, proof)
+}
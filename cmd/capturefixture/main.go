@@ -0,0 +1,138 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+// capturefixture records a block from a node into the fixture format of txtrie/fixtures. It reads
+// the block through the debug_getRaw* methods, so it works for every transaction type.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ChainSafe/chainbridge-ethereum-trie/txtrie"
+	"github.com/ChainSafe/chainbridge-ethereum-trie/txtrie/fixtures"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func main() {
+	url := flag.String("rpc", "http://localhost:8545", "RPC endpoint of a node exposing the debug namespace")
+	number := flag.Uint64("block", 0, "number of the block to capture")
+	indices := flag.String("indices", "0", "comma separated indices of the transactions and receipts to prove")
+	name := flag.String("name", "", "fixture name, defaults to <source>-<block>")
+	source := flag.String("source", "mainnet", "network the block comes from")
+	fork := flag.String("fork", "legacy", "newest transaction format in the block")
+	out := flag.String("out", "", "output file, defaults to txtrie/fixtures/testdata/<name>.json")
+	flag.Parse()
+
+	if *name == "" {
+		*name = fmt.Sprintf("%s-%d", *source, *number)
+	}
+	if *out == "" {
+		*out = fmt.Sprintf("txtrie/fixtures/testdata/%s.json", *name)
+	}
+
+	fixture, err := capture(*url, *number, *indices)
+	if err == nil {
+		fixture.Name, fixture.Source, fixture.Fork = *name, *source, *fork
+		err = fixture.Save(*out)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Println("wrote", *out)
+}
+
+func capture(url string, number uint64, indices string) (*fixtures.Fixture, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	client, err := rpc.DialContext(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	blockNumber := hexutil.EncodeUint64(number)
+	var header, block hexutil.Bytes
+	var receipts []hexutil.Bytes
+	if err := client.CallContext(ctx, &header, "debug_getRawHeader", blockNumber); err != nil {
+		return nil, fmt.Errorf("debug_getRawHeader: %v", err)
+	}
+	if err := client.CallContext(ctx, &block, "debug_getRawBlock", blockNumber); err != nil {
+		return nil, fmt.Errorf("debug_getRawBlock: %v", err)
+	}
+	if err := client.CallContext(ctx, &receipts, "debug_getRawReceipts", blockNumber); err != nil {
+		return nil, fmt.Errorf("debug_getRawReceipts: %v", err)
+	}
+
+	txs, err := fixtures.BlockTransactions(block)
+	if err != nil {
+		return nil, err
+	}
+
+	fixture := &fixtures.Fixture{
+		Name:        "capture",
+		BlockNumber: hexutil.Uint64(number),
+		BlockHash:   crypto.Keccak256Hash(header),
+		Header:      header,
+		Receipts:    receipts,
+	}
+	for _, tx := range txs {
+		fixture.Transactions = append(fixture.Transactions, tx)
+	}
+
+	// the roots are taken from the header, building the tries checks the captured values against them
+	if fixture.TransactionsRoot, fixture.ReceiptsRoot, err = fixtures.HeaderRoots(header); err != nil {
+		return nil, err
+	}
+	txTries := txtrie.NewTxTries()
+	for _, trie := range []string{fixtures.TransactionsTrie, fixtures.ReceiptsTrie} {
+		values, err := fixture.Values(trie)
+		if err != nil {
+			return nil, err
+		}
+		root, err := fixture.Root(trie)
+		if err != nil {
+			return nil, err
+		}
+		if err := txTries.CreateNewRawTrie(root, values); err != nil {
+			return nil, fmt.Errorf("%s trie: %v", trie, err)
+		}
+	}
+
+	for _, field := range strings.Split(indices, ",") {
+		index, err := strconv.ParseUint(strings.TrimSpace(field), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid index %q: %v", field, err)
+		}
+		if index >= uint64(len(txs)) {
+			return nil, fmt.Errorf("index %d out of range for %d transactions", index, len(txs))
+		}
+		key, err := rlp.EncodeToBytes(uint(index))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, trie := range []string{fixtures.TransactionsTrie, fixtures.ReceiptsTrie} {
+			root, err := fixture.Root(trie)
+			if err != nil {
+				return nil, err
+			}
+			encodedProof, err := txTries.RetrieveEncodedProof(root, key)
+			if err != nil {
+				return nil, err
+			}
+			fixture.Proofs = append(fixture.Proofs, fixtures.ProofVector{Trie: trie, Index: index, EncodedProof: encodedProof})
+		}
+	}
+	return fixture, nil
+}
@@ -0,0 +1,434 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+// synthfixture generates the synthetic fixtures of txtrie/fixtures/testdata. The blocks are
+// deterministic, so running it again reproduces the committed files. They don't come from any
+// network and their block numbers are made up. Typed transactions are built and signed here,
+// as this version of go-ethereum only knows legacy transactions.
+package main
+
+import (
+	"crypto/ecdsa"
+	"flag"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"os"
+	"path/filepath"
+
+	"github.com/ChainSafe/chainbridge-ethereum-trie/txtrie"
+	"github.com/ChainSafe/chainbridge-ethereum-trie/txtrie/fixtures"
+	"github.com/ChainSafe/chainbridge-ethereum-trie/txtrie/txgen"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	ethtrie "github.com/ethereum/go-ethereum/trie"
+)
+
+// EIP-2718 transaction types
+const (
+	legacyTxType     = 0x00
+	accessListTxType = 0x01
+	dynamicFeeTxType = 0x02
+	blobTxType       = 0x03
+)
+
+const (
+	txCount        = 140
+	accounts       = 4
+	blobGasPerBlob = 1 << 17
+)
+
+var chainID = big.NewInt(1337)
+
+// era describes the synthetic block generated for a fork
+type era struct {
+	fork    string
+	number  uint64
+	txTypes []byte
+}
+
+var eras = []era{
+	{fork: "berlin", number: 2, txTypes: []byte{legacyTxType, accessListTxType}},
+	{fork: "london", number: 3, txTypes: []byte{legacyTxType, accessListTxType, dynamicFeeTxType}},
+	{fork: "cancun", number: 4, txTypes: []byte{legacyTxType, accessListTxType, dynamicFeeTxType, blobTxType}},
+}
+
+func main() {
+	dir := flag.String("dir", "txtrie/fixtures/testdata", "directory the fixtures are written to")
+	flag.Parse()
+
+	if err := generate(*dir); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func generate(dir string) error {
+	fixture, err := legacyFixture()
+	if err != nil {
+		return fmt.Errorf("legacy: %v", err)
+	}
+	if err := save(dir, fixture); err != nil {
+		return err
+	}
+
+	for i, e := range eras {
+		b := newBuilder(int64(i+1), e)
+		fixture, err := b.fixture()
+		if err != nil {
+			return fmt.Errorf("%s: %v", e.fork, err)
+		}
+		if err := save(dir, fixture); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func save(dir string, fixture *fixtures.Fixture) error {
+	path := filepath.Join(dir, fixture.Name+".json")
+	if err := fixture.Save(path); err != nil {
+		return err
+	}
+	fmt.Println("wrote", path)
+	return nil
+}
+
+// legacyFixture is the first block of a txgen chain, which only holds legacy transactions
+func legacyFixture() (*fixtures.Fixture, error) {
+	gen, err := txgen.NewGenerator(txgen.Config{})
+	if err != nil {
+		return nil, err
+	}
+	block, receipts, err := gen.Block(44)
+	if err != nil {
+		return nil, err
+	}
+
+	header, err := rlp.EncodeToBytes(block.Header())
+	if err != nil {
+		return nil, err
+	}
+	txs := make([]hexutil.Bytes, len(block.Transactions()))
+	for i, tx := range block.Transactions() {
+		if txs[i], err = rlp.EncodeToBytes(tx); err != nil {
+			return nil, err
+		}
+	}
+	encodedReceipts := make([]hexutil.Bytes, len(receipts))
+	for i, receipt := range receipts {
+		if encodedReceipts[i], err = rlp.EncodeToBytes(receipt); err != nil {
+			return nil, err
+		}
+	}
+	return newFixture("legacy", header, txs, encodedReceipts)
+}
+
+// newFixture creates the fixture of a block, checking its values against the roots of header and
+// recording the proofs of the first, second, seventeenth and last values
+func newFixture(fork string, header []byte, txs, receipts []hexutil.Bytes) (*fixtures.Fixture, error) {
+	fixture := &fixtures.Fixture{
+		Name:         "synthetic-" + fork,
+		Source:       "synthetic",
+		Fork:         fork,
+		BlockHash:    crypto.Keccak256Hash(header),
+		Header:       header,
+		Transactions: txs,
+		Receipts:     receipts,
+	}
+
+	var h struct {
+		ParentHash, UncleHash common.Hash
+		Coinbase              common.Address
+		Root, TxHash          common.Hash
+		ReceiptHash           common.Hash
+		Bloom                 types.Bloom
+		Difficulty, Number    *big.Int
+		Rest                  []rlp.RawValue `rlp:"tail"`
+	}
+	if err := rlp.DecodeBytes(header, &h); err != nil {
+		return nil, err
+	}
+	fixture.BlockNumber = hexutil.Uint64(h.Number.Uint64())
+	fixture.TransactionsRoot, fixture.ReceiptsRoot = h.TxHash, h.ReceiptHash
+
+	txTries := txtrie.NewTxTries()
+	for _, trie := range fixture.Tries() {
+		values, err := fixture.Values(trie)
+		if err != nil {
+			return nil, err
+		}
+		root, err := fixture.Root(trie)
+		if err != nil {
+			return nil, err
+		}
+		if err := txTries.CreateNewRawTrie(root, values); err != nil {
+			return nil, fmt.Errorf("%s trie: %v", trie, err)
+		}
+	}
+
+	last := uint64(len(txs) - 1)
+	for _, index := range []uint64{0, 1, 16, last} {
+		key, err := rlp.EncodeToBytes(uint(index))
+		if err != nil {
+			return nil, err
+		}
+		for _, trie := range fixture.Tries() {
+			// a single receipt in the middle of the block is enough
+			if trie == fixtures.ReceiptsTrie && index == 16 {
+				continue
+			}
+			root, err := fixture.Root(trie)
+			if err != nil {
+				return nil, err
+			}
+			encodedProof, err := txTries.RetrieveEncodedProof(root, key)
+			if err != nil {
+				return nil, err
+			}
+			fixture.Proofs = append(fixture.Proofs, fixtures.ProofVector{Trie: trie, Index: index, EncodedProof: encodedProof})
+		}
+	}
+	return fixture, nil
+}
+
+// builder generates a block mixing the transaction types of an era
+type builder struct {
+	era    era
+	rng    *rand.Rand
+	keys   []*ecdsa.PrivateKey
+	nonces []uint64
+
+	txs       []hexutil.Bytes
+	receipts  []hexutil.Bytes
+	bloom     types.Bloom
+	gasUsed   uint64
+	blobCount int
+}
+
+func newBuilder(seed int64, e era) *builder {
+	b := &builder{
+		era:    e,
+		rng:    rand.New(rand.NewSource(seed)),
+		nonces: make([]uint64, accounts),
+	}
+	for len(b.keys) < accounts {
+		// the hash may fall outside of the curve order, skip it then
+		key, err := crypto.ToECDSA(crypto.Keccak256([]byte(e.fork), []byte{byte(len(b.keys))}, b.bytes(8)))
+		if err != nil {
+			continue
+		}
+		b.keys = append(b.keys, key)
+	}
+	return b
+}
+
+func (b *builder) fixture() (*fixtures.Fixture, error) {
+	for i := 0; i < txCount; i++ {
+		txType := b.era.txTypes[i%len(b.era.txTypes)]
+		kind := txgen.AllKinds[i/len(b.era.txTypes)%len(txgen.AllKinds)]
+		// blob transactions can't create contracts
+		if txType == blobTxType && kind == txgen.ContractCreation {
+			kind = txgen.ContractCall
+		}
+		if err := b.addTransaction(txType, kind); err != nil {
+			return nil, fmt.Errorf("transaction %d: %v", i, err)
+		}
+	}
+
+	header, err := b.header()
+	if err != nil {
+		return nil, err
+	}
+	return newFixture(b.era.fork, header, b.txs, b.receipts)
+}
+
+// addTransaction signs a transaction of txType doing kind and adds it with its receipt
+func (b *builder) addTransaction(txType byte, kind txgen.TxKind) error {
+	account := b.rng.Intn(len(b.keys))
+	nonce := b.nonces[account]
+	b.nonces[account]++
+
+	var to *common.Address
+	var gas uint64
+	var data []byte
+	value := new(big.Int)
+	switch kind {
+	case txgen.Transfer:
+		to, gas = b.address(), 21000
+		value.Mul(big.NewInt(int64(1+b.rng.Intn(1000))), big.NewInt(1e15))
+	case txgen.ContractCreation:
+		gas, data = 100000+uint64(b.rng.Intn(400000)), b.bytes(10+b.rng.Intn(500))
+	case txgen.ContractCall:
+		// a selector followed by 32 byte words
+		to, gas, data = b.address(), 30000+uint64(b.rng.Intn(200000)), b.bytes(4+32*b.rng.Intn(8))
+	}
+	tip := new(big.Int).Mul(big.NewInt(int64(1+b.rng.Intn(3))), big.NewInt(1e9))
+	feeCap := new(big.Int).Mul(big.NewInt(int64(10+b.rng.Intn(90))), big.NewInt(1e9))
+
+	var fields []interface{}
+	switch txType {
+	case legacyTxType:
+		var tx *types.Transaction
+		if to == nil {
+			tx = types.NewContractCreation(nonce, value, gas, feeCap, data)
+		} else {
+			tx = types.NewTransaction(nonce, *to, value, gas, feeCap, data)
+		}
+		signedTx, err := types.SignTx(tx, types.NewEIP155Signer(chainID), b.keys[account])
+		if err != nil {
+			return err
+		}
+		enc, err := rlp.EncodeToBytes(signedTx)
+		if err != nil {
+			return err
+		}
+		return b.add(txType, enc, gas, to, data)
+	case accessListTxType:
+		fields = []interface{}{chainID, nonce, feeCap, gas, recipient(to), value, data, b.accessList()}
+	case dynamicFeeTxType:
+		fields = []interface{}{chainID, nonce, tip, feeCap, gas, recipient(to), value, data, b.accessList()}
+	case blobTxType:
+		hashes := make([]common.Hash, 1+b.rng.Intn(2))
+		for i := range hashes {
+			copy(hashes[i][:], b.bytes(common.HashLength))
+			// versioned hashes of KZG commitments
+			hashes[i][0] = 0x01
+		}
+		b.blobCount += len(hashes)
+		fields = []interface{}{chainID, nonce, tip, feeCap, gas, *to, value, data, b.accessList(), big.NewInt(1), hashes}
+	default:
+		return fmt.Errorf("unknown transaction type %d", txType)
+	}
+
+	// typed transactions sign the hash of their type followed by the unsigned fields
+	unsigned, err := rlp.EncodeToBytes(fields)
+	if err != nil {
+		return err
+	}
+	sig, err := crypto.Sign(crypto.Keccak256([]byte{txType}, unsigned), b.keys[account])
+	if err != nil {
+		return err
+	}
+	fields = append(fields, sig[64], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:64]))
+	enc, err := rlp.EncodeToBytes(fields)
+	if err != nil {
+		return err
+	}
+	return b.add(txType, append([]byte{txType}, enc...), gas, to, data)
+}
+
+// add appends the transaction value enc and its successful receipt, every contract call emits one log
+func (b *builder) add(txType byte, enc []byte, gas uint64, to *common.Address, data []byte) error {
+	// the whole gas limit is used, which keeps the amounts deterministic
+	b.gasUsed += gas
+
+	logs := []*types.Log{}
+	if to != nil && len(data) > 0 {
+		logs = append(logs, &types.Log{
+			Address: *to,
+			Topics:  []common.Hash{crypto.Keccak256Hash(data[:4])},
+			Data:    common.CopyBytes(data[4:]),
+		})
+	}
+	bloom := types.BytesToBloom(types.LogsBloom(logs))
+	for i := range b.bloom {
+		b.bloom[i] |= bloom[i]
+	}
+
+	receipt, err := rlp.EncodeToBytes([]interface{}{[]byte{0x01}, b.gasUsed, bloom, logs})
+	if err != nil {
+		return err
+	}
+	if txType != legacyTxType {
+		receipt = append([]byte{txType}, receipt...)
+	}
+
+	b.txs = append(b.txs, enc)
+	b.receipts = append(b.receipts, receipt)
+	return nil
+}
+
+// header encodes a header with the fields of the era committing to the generated values
+func (b *builder) header() ([]byte, error) {
+	txRoot := types.DeriveSha(rawValues(b.txs), new(ethtrie.Trie))
+	receiptRoot := types.DeriveSha(rawValues(b.receipts), new(ethtrie.Trie))
+
+	gasLimit := uint64(30000000)
+	if b.gasUsed > gasLimit {
+		gasLimit = b.gasUsed
+	}
+	difficulty := big.NewInt(1)
+	if b.era.fork == "cancun" {
+		// proof of stake blocks have no difficulty
+		difficulty = new(big.Int)
+	}
+
+	fields := []interface{}{
+		common.BytesToHash(b.bytes(common.HashLength)),
+		types.EmptyUncleHash,
+		*b.address(),
+		common.BytesToHash(b.bytes(common.HashLength)),
+		txRoot,
+		receiptRoot,
+		b.bloom,
+		difficulty,
+		b.era.number,
+		gasLimit,
+		b.gasUsed,
+		uint64(1600000000 + 12*b.era.number),
+		[]byte{},
+		common.BytesToHash(b.bytes(common.HashLength)),
+		types.BlockNonce{},
+	}
+	if b.era.fork == "london" || b.era.fork == "cancun" {
+		// base fee
+		fields = append(fields, big.NewInt(7e9))
+	}
+	if b.era.fork == "cancun" {
+		// withdrawals root, blob gas used, excess blob gas and parent beacon block root
+		fields = append(fields, types.EmptyRootHash, uint64(b.blobCount*blobGasPerBlob), uint64(0), common.BytesToHash(b.bytes(common.HashLength)))
+	}
+	return rlp.EncodeToBytes(fields)
+}
+
+// accessList returns a random access list of up to two addresses
+func (b *builder) accessList() []interface{} {
+	list := []interface{}{}
+	for i := b.rng.Intn(3); i > 0; i-- {
+		keys := make([]common.Hash, b.rng.Intn(3))
+		for j := range keys {
+			keys[j] = common.BytesToHash(b.bytes(common.HashLength))
+		}
+		list = append(list, []interface{}{*b.address(), keys})
+	}
+	return list
+}
+
+func (b *builder) address() *common.Address {
+	address := common.BytesToAddress(b.bytes(common.AddressLength))
+	return &address
+}
+
+func (b *builder) bytes(n int) []byte {
+	buf := make([]byte, n)
+	b.rng.Read(buf)
+	return buf
+}
+
+// recipient returns the encoding of to, contract creations have an empty recipient
+func recipient(to *common.Address) []byte {
+	if to == nil {
+		return []byte{}
+	}
+	return to[:]
+}
+
+// rawValues are trie values that are already encoded
+type rawValues []hexutil.Bytes
+
+func (v rawValues) Len() int            { return len(v) }
+func (v rawValues) GetRlp(i int) []byte { return v[i] }
@@ -0,0 +1,331 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+pragma solidity ^0.8.0;
+
+/// @title TxProofVerifier
+/// @notice Verifies merkle patricia trie proofs produced by txtrie's RetrieveEncodedProof.
+/// @dev The proof is an RLP list holding the nodes on the path of the key. Full nodes are
+/// 17 item lists and short nodes are [key, value] lists whose key is stored as one byte per
+/// nibble, ending with 16 for leaves. Embedded nodes use the same layout. Every node is
+/// re-encoded in its canonical form before being checked against the hash referencing it.
+/// The steps mirror VerifyEncodedProof in the Go package.
+contract TxProofVerifier {
+    uint8 private constant TERMINATOR = 16;
+
+    // Item is a view of an RLP item in memory, ptr points at its prefix and len covers prefix and payload
+    struct Item {
+        uint256 ptr;
+        uint256 len;
+    }
+
+    /// @notice Verifies proof on path key against root
+    /// @param root the root hash of the trie
+    /// @param key the key of the value, for transaction tries the rlp encoded transaction index
+    /// @param proof the encoded proof
+    /// @return exists whether the trie contains the key
+    /// @return value the value stored at key
+    function verifyProof(bytes32 root, bytes memory key, bytes memory proof) public pure returns (bool exists, bytes memory value) {
+        bytes memory nibbles = toNibbles(key);
+        Item[] memory nodes = toList(toItem(proof));
+        bytes32 wantHash = root;
+        uint256 pos = 0;
+
+        for (uint256 i = 0; i < nodes.length; i++) {
+            require(isList(nodes[i]), "proof node is not a list");
+            require(keccak256(encodeNode(nodes[i])) == wantHash, "proof node hash mismatch");
+
+            // walk the node, descending into embedded children, until a hash, value or dead end is reached
+            Item memory current = nodes[i];
+            while (true) {
+                Item[] memory elems = toList(current);
+                Item memory child;
+                bool isValue;
+
+                if (elems.length == 17) {
+                    require(pos < nibbles.length, "key ends mid path");
+                    uint8 nibble = uint8(nibbles[pos]);
+                    child = elems[nibble];
+                    isValue = nibble == TERMINATOR;
+                    pos++;
+                } else if (elems.length == 2) {
+                    bytes memory nodeKey = toBytes(elems[0]);
+                    validateNibbles(nodeKey);
+                    if (!hasPrefix(nibbles, pos, nodeKey)) {
+                        return (false, "");
+                    }
+                    child = elems[1];
+                    isValue = uint8(nodeKey[nodeKey.length - 1]) == TERMINATOR;
+                    pos += nodeKey.length;
+                } else {
+                    revert("invalid number of list elements");
+                }
+
+                if (isValue) {
+                    value = toBytes(child);
+                    return (value.length > 0, value);
+                }
+
+                if (isList(child)) {
+                    current = child;
+                    continue;
+                }
+
+                bytes memory ref = toBytes(child);
+                if (ref.length == 0) {
+                    return (false, "");
+                }
+                require(ref.length == 32, "invalid child reference");
+                assembly {
+                    wantHash := mload(add(ref, 32))
+                }
+                break;
+            }
+        }
+
+        revert("proof node missing");
+    }
+
+    // encodeNode returns the canonical RLP encoding of a node in proof format
+    function encodeNode(Item memory node) internal pure returns (bytes memory) {
+        Item[] memory elems = toList(node);
+        bytes[] memory parts = new bytes[](elems.length);
+
+        if (elems.length == 2) {
+            bytes memory nodeKey = toBytes(elems[0]);
+            validateNibbles(nodeKey);
+            parts[0] = encodeBytes(toCompact(nodeKey));
+            parts[1] = encodeChild(elems[1]);
+        } else if (elems.length == 17) {
+            for (uint256 i = 0; i < 17; i++) {
+                parts[i] = encodeChild(elems[i]);
+            }
+        } else {
+            revert("invalid number of list elements");
+        }
+
+        return encodeList(parts);
+    }
+
+    // encodeChild returns the canonical encoding of a child within its parent
+    function encodeChild(Item memory item) internal pure returns (bytes memory) {
+        if (isList(item)) {
+            bytes memory enc = encodeNode(item);
+            require(enc.length < 32, "oversized embedded node");
+            return enc;
+        }
+        return encodeBytes(toBytes(item));
+    }
+
+    // toNibbles expands key to one nibble per byte followed by the terminator
+    function toNibbles(bytes memory key) internal pure returns (bytes memory nibbles) {
+        nibbles = new bytes(key.length * 2 + 1);
+        for (uint256 i = 0; i < key.length; i++) {
+            nibbles[i * 2] = bytes1(uint8(key[i]) / 16);
+            nibbles[i * 2 + 1] = bytes1(uint8(key[i]) % 16);
+        }
+        nibbles[nibbles.length - 1] = bytes1(TERMINATOR);
+    }
+
+    // toCompact converts a nibble key to its hex prefix encoding
+    function toCompact(bytes memory nibbles) internal pure returns (bytes memory compact) {
+        uint256 length = nibbles.length;
+        uint8 flag = 0;
+        if (length > 0 && uint8(nibbles[length - 1]) == TERMINATOR) {
+            flag = 2;
+            length--;
+        }
+
+        compact = new bytes(length / 2 + 1);
+        uint256 start = 0;
+        if (length % 2 == 1) {
+            flag += 1;
+            compact[0] = bytes1(uint8(nibbles[0]));
+            start = 1;
+        }
+        compact[0] = bytes1(uint8(compact[0]) | (flag << 4));
+
+        for (uint256 i = start; i < length; i += 2) {
+            compact[(i - start) / 2 + 1] = bytes1((uint8(nibbles[i]) << 4) | uint8(nibbles[i + 1]));
+        }
+    }
+
+    // validateNibbles checks that key is a non empty nibble path with an optional terminator at the end
+    function validateNibbles(bytes memory key) internal pure {
+        require(key.length > 0, "empty short node key");
+        for (uint256 i = 0; i < key.length; i++) {
+            uint8 nibble = uint8(key[i]);
+            require(nibble < TERMINATOR || (nibble == TERMINATOR && i == key.length - 1), "invalid nibble");
+        }
+    }
+
+    // hasPrefix checks if nibbles starting at pos begins with prefix
+    function hasPrefix(bytes memory nibbles, uint256 pos, bytes memory prefix) internal pure returns (bool) {
+        if (pos + prefix.length > nibbles.length) {
+            return false;
+        }
+        for (uint256 i = 0; i < prefix.length; i++) {
+            if (nibbles[pos + i] != prefix[i]) {
+                return false;
+            }
+        }
+        return true;
+    }
+
+    // RLP decoding
+
+    // toItem wraps b, which must hold exactly one RLP item
+    function toItem(bytes memory b) internal pure returns (Item memory) {
+        require(b.length > 0, "empty input");
+        uint256 ptr;
+        assembly {
+            ptr := add(b, 32)
+        }
+        (uint256 offset, uint256 length, ) = header(ptr);
+        require(offset + length == b.length, "trailing bytes");
+        return Item(ptr, b.length);
+    }
+
+    // header decodes the prefix at ptr and returns the payload offset, the payload length and whether the item is a list
+    function header(uint256 ptr) internal pure returns (uint256 offset, uint256 length, bool list) {
+        uint256 b0;
+        uint256 b1;
+        assembly {
+            b0 := byte(0, mload(ptr))
+            b1 := byte(1, mload(ptr))
+        }
+
+        if (b0 < 0x80) {
+            return (0, 1, false);
+        } else if (b0 < 0xb8) {
+            length = b0 - 0x80;
+            require(length != 1 || b1 >= 0x80, "non canonical size");
+            return (1, length, false);
+        } else if (b0 < 0xc0) {
+            (offset, length) = longLength(ptr, b0 - 0xb7);
+            return (offset, length, false);
+        } else if (b0 < 0xf8) {
+            return (1, b0 - 0xc0, true);
+        }
+        (offset, length) = longLength(ptr, b0 - 0xf7);
+        return (offset, length, true);
+    }
+
+    // longLength decodes the lengthOfLength bytes following the prefix at ptr
+    function longLength(uint256 ptr, uint256 lengthOfLength) private pure returns (uint256 offset, uint256 length) {
+        require(lengthOfLength <= 8, "size too large");
+        uint256 first;
+        assembly {
+            let word := mload(add(ptr, 1))
+            first := byte(0, word)
+            length := shr(mul(8, sub(32, lengthOfLength)), word)
+        }
+        require(first != 0 && length >= 56, "non canonical size");
+        return (1 + lengthOfLength, length);
+    }
+
+    function isList(Item memory item) internal pure returns (bool) {
+        uint256 b0;
+        uint256 ptr = item.ptr;
+        assembly {
+            b0 := byte(0, mload(ptr))
+        }
+        return b0 >= 0xc0;
+    }
+
+    // toList returns the items of a list item
+    function toList(Item memory item) internal pure returns (Item[] memory items) {
+        (uint256 offset, uint256 length, bool list) = header(item.ptr);
+        require(list, "item is not a list");
+        require(offset + length == item.len, "invalid list size");
+
+        uint256 end = item.ptr + item.len;
+        uint256 count = 0;
+        uint256 ptr = item.ptr + offset;
+        while (ptr < end) {
+            ptr += itemLength(ptr, end);
+            count++;
+        }
+
+        items = new Item[](count);
+        ptr = item.ptr + offset;
+        for (uint256 i = 0; i < count; i++) {
+            uint256 itemLen = itemLength(ptr, end);
+            items[i] = Item(ptr, itemLen);
+            ptr += itemLen;
+        }
+    }
+
+    // itemLength returns the total length of the item at ptr, which must end before end
+    function itemLength(uint256 ptr, uint256 end) private pure returns (uint256) {
+        (uint256 offset, uint256 length, ) = header(ptr);
+        require(ptr + offset + length <= end, "value size exceeds available input length");
+        return offset + length;
+    }
+
+    // toBytes copies the payload of a string item
+    function toBytes(Item memory item) internal pure returns (bytes memory out) {
+        (uint256 offset, uint256 length, bool list) = header(item.ptr);
+        require(!list, "item is not a string");
+
+        out = new bytes(length);
+        uint256 dest;
+        assembly {
+            dest := add(out, 32)
+        }
+        copyMemory(item.ptr + offset, dest, length);
+    }
+
+    function copyMemory(uint256 src, uint256 dest, uint256 length) private pure {
+        for (; length >= 32; length -= 32) {
+            assembly {
+                mstore(dest, mload(src))
+            }
+            src += 32;
+            dest += 32;
+        }
+        if (length > 0) {
+            uint256 mask = 256**(32 - length) - 1;
+            assembly {
+                let srcPart := and(mload(src), not(mask))
+                let destPart := and(mload(dest), mask)
+                mstore(dest, or(destPart, srcPart))
+            }
+        }
+    }
+
+    // RLP encoding
+
+    function encodeBytes(bytes memory b) internal pure returns (bytes memory) {
+        if (b.length == 1 && uint8(b[0]) < 0x80) {
+            return b;
+        }
+        return abi.encodePacked(encodeLength(b.length, 0x80), b);
+    }
+
+    function encodeList(bytes[] memory parts) internal pure returns (bytes memory) {
+        bytes memory payload;
+        for (uint256 i = 0; i < parts.length; i++) {
+            payload = abi.encodePacked(payload, parts[i]);
+        }
+        return abi.encodePacked(encodeLength(payload.length, 0xc0), payload);
+    }
+
+    function encodeLength(uint256 length, uint256 offset) private pure returns (bytes memory) {
+        if (length < 56) {
+            return abi.encodePacked(uint8(length + offset));
+        }
+
+        uint256 lengthOfLength = 0;
+        for (uint256 l = length; l != 0; l >>= 8) {
+            lengthOfLength++;
+        }
+
+        bytes memory encoded = new bytes(lengthOfLength + 1);
+        encoded[0] = bytes1(uint8(lengthOfLength + offset + 55));
+        for (uint256 i = 0; i < lengthOfLength; i++) {
+            encoded[lengthOfLength - i] = bytes1(uint8(length >> (8 * i)));
+        }
+        return encoded;
+    }
+}
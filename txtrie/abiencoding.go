@@ -0,0 +1,162 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package txtrie
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// abiProofTypes are the types of the (root, key, nodes, header) tuple, header is optional
+var abiProofTypes = []string{"bytes32", "bytes", "bytes[]", "bytes"}
+
+// ABIProof is the content of an ABI encoded proof
+type ABIProof struct {
+	Root   common.Hash
+	Key    []byte
+	Nodes  [][]byte // canonical RLP encoding of the nodes on the path of Key, starting at the root
+	Header []byte   // RLP encoded block header, nil if the layout has no header
+}
+
+// ABIProofEncoder encodes proofs as ABI encoded (root, key, nodes[, header]) tuples,
+// optionally prefixed with the selector of the handler method taking them
+type ABIProofEncoder struct {
+	args     abi.Arguments
+	selector []byte
+	header   []byte
+}
+
+// ABITupleEncoder encodes proofs as a plain ABI encoded (bytes32 root, bytes key, bytes[] nodes) tuple
+var ABITupleEncoder = mustNewABITupleEncoder(false)
+
+// NewABITupleEncoder creates an ABIProofEncoder producing plain ABI encoded tuples without selector,
+// withHeader adds a trailing bytes header element to the tuple
+func NewABITupleEncoder(withHeader bool) (*ABIProofEncoder, error) {
+	count := 3
+	if withHeader {
+		count = 4
+	}
+
+	var args abi.Arguments
+	for i := 0; i < count; i++ {
+		typ, err := abi.NewType(abiProofTypes[i], "", nil)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, abi.Argument{Type: typ})
+	}
+	return &ABIProofEncoder{args: args}, nil
+}
+
+func mustNewABITupleEncoder(withHeader bool) *ABIProofEncoder {
+	encoder, err := NewABITupleEncoder(withHeader)
+	if err != nil {
+		panic(err)
+	}
+	return encoder
+}
+
+// NewABIProofEncoder creates an ABIProofEncoder producing calldata for method of the contract
+// described by the JSON handlerABI. The method must take (bytes32, bytes, bytes[]) or
+// (bytes32, bytes, bytes[], bytes) arguments.
+func NewABIProofEncoder(handlerABI string, method string) (*ABIProofEncoder, error) {
+	parsed, err := abi.JSON(strings.NewReader(handlerABI))
+	if err != nil {
+		return nil, err
+	}
+
+	m, exists := parsed.Methods[method]
+	if !exists {
+		return nil, fmt.Errorf("method %s not found in handler abi", method)
+	}
+
+	if len(m.Inputs) != 3 && len(m.Inputs) != 4 {
+		return nil, fmt.Errorf("method %s takes %d arguments, want 3 or 4", m.Sig, len(m.Inputs))
+	}
+	for i, input := range m.Inputs {
+		if input.Type.String() != abiProofTypes[i] {
+			return nil, fmt.Errorf("argument %d of method %s is %s, want %s", i, m.Sig, input.Type, abiProofTypes[i])
+		}
+	}
+
+	return &ABIProofEncoder{args: m.Inputs, selector: m.ID}, nil
+}
+
+// WithHeader returns a copy of the encoder that places the RLP encoding of header in the header argument
+func (e *ABIProofEncoder) WithHeader(header *types.Header) (*ABIProofEncoder, error) {
+	if len(e.args) != 4 {
+		return nil, errors.New("abi layout has no header argument")
+	}
+
+	enc, err := rlp.EncodeToBytes(header)
+	if err != nil {
+		return nil, err
+	}
+
+	encoder := *e
+	encoder.header = enc
+	return &encoder, nil
+}
+
+// Encode ABI encodes the nodes of proofDb on the path of key in the trie with root root
+func (e *ABIProofEncoder) Encode(root common.Hash, key []byte, proofDb *ProofDatabase) ([]byte, error) {
+	nodes, err := proofPathNodes(root, key, proofDb)
+	if err != nil {
+		return nil, err
+	}
+
+	values := []interface{}{[32]byte(root), key, nodes}
+	if len(e.args) == 4 {
+		header := e.header
+		if header == nil {
+			header = []byte{}
+		}
+		values = append(values, header)
+	}
+
+	packed, err := e.args.Pack(values...)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(common.CopyBytes(e.selector), packed...), nil
+}
+
+// Decode parses an ABI encoded proof into a ProofDatabase
+func (e *ABIProofEncoder) Decode(encodedProof []byte) (*ProofDatabase, error) {
+	proof, err := e.DecodeABIProof(encodedProof)
+	if err != nil {
+		return nil, err
+	}
+	return nodesToProofDB(proof.Nodes)
+}
+
+// DecodeABIProof parses all elements of an ABI encoded proof
+func (e *ABIProofEncoder) DecodeABIProof(encodedProof []byte) (*ABIProof, error) {
+	if len(encodedProof) < len(e.selector) || !bytes.Equal(encodedProof[:len(e.selector)], e.selector) {
+		return nil, errors.New("method selector mismatch")
+	}
+
+	values, err := e.args.UnpackValues(encodedProof[len(e.selector):])
+	if err != nil {
+		return nil, err
+	}
+
+	proof := &ABIProof{
+		Root:  values[0].([32]byte),
+		Key:   values[1].([]byte),
+		Nodes: values[2].([][]byte),
+	}
+	if len(values) == 4 {
+		proof.Header = values[3].([]byte)
+	}
+	return proof, nil
+}
@@ -0,0 +1,140 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package txtrie
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+const testHandlerABI = `[
+	{"inputs":[{"name":"root","type":"bytes32"},{"name":"key","type":"bytes"},{"name":"proof","type":"bytes[]"},{"name":"header","type":"bytes"}],"name":"executeProof","outputs":[],"stateMutability":"nonpayable","type":"function"},
+	{"inputs":[{"name":"root","type":"bytes32"},{"name":"proof","type":"bytes"}],"name":"invalidLayout","outputs":[],"stateMutability":"nonpayable","type":"function"}
+]`
+
+func TestABIProofEncoderHandlerMethod(t *testing.T) {
+	vals := GetTransactions1()
+	expectedRoot, err := computeEthReferenceTrieHash(vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	txTries := NewTxTries()
+	err = addTrie(txTries, expectedRoot, vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := rlp.EncodeToBytes(uint(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encoder, err := NewABIProofEncoder(testHandlerABI, "executeProof")
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := &types.Header{Number: big.NewInt(10), TxHash: expectedRoot}
+	encoder, err = encoder.WithHeader(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calldata, err := txTries.RetrieveProofWithEncoder(expectedRoot, key, encoder)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := abi.JSON(strings.NewReader(testHandlerABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(calldata[:4], parsed.Methods["executeProof"].ID) {
+		t.Fatalf("calldata does not start with method selector: %x", calldata[:4])
+	}
+
+	proof, err := encoder.DecodeABIProof(calldata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proof.Root != expectedRoot || !bytes.Equal(proof.Key, key) || len(proof.Nodes) == 0 {
+		t.Fatalf("decoded proof does not match: %+v", proof)
+	}
+
+	var decodedHeader types.Header
+	if err := rlp.DecodeBytes(proof.Header, &decodedHeader); err != nil {
+		t.Fatal(err)
+	}
+	if decodedHeader.Hash() != header.Hash() {
+		t.Fatalf("decoded header does not match, expected: %x, got: %x", header.Hash(), decodedHeader.Hash())
+	}
+
+	proofDb, err := encoder.Decode(calldata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exists, err := VerifyProof(expectedRoot, key, proofDb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !exists {
+		t.Fatalf("not able to verify decoded proof")
+	}
+}
+
+func TestABITupleEncoderRoundTrip(t *testing.T) {
+	vals := GetTransactions3()
+	expectedRoot, err := computeEthReferenceTrieHash(vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	txTries := NewTxTries()
+	err = addTrie(txTries, expectedRoot, vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := rlp.EncodeToBytes(uint(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encodedProof, err := txTries.RetrieveProofWithEncoder(expectedRoot, key, ABITupleEncoder)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proofDb, err := ABITupleEncoder.Decode(encodedProof)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reencoded, err := ABITupleEncoder.Encode(expectedRoot, key, proofDb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(encodedProof, reencoded) {
+		t.Fatalf("round trip changed the encoding, expected: %x, got: %x", encodedProof, reencoded)
+	}
+
+	if _, err := ABITupleEncoder.WithHeader(&types.Header{}); err == nil {
+		t.Fatalf("expected adding a header to a layout without header to fail")
+	}
+}
+
+func TestNewABIProofEncoderInvalidLayout(t *testing.T) {
+	if _, err := NewABIProofEncoder(testHandlerABI, "invalidLayout"); err == nil {
+		t.Fatalf("expected invalid layout to fail")
+	}
+	if _, err := NewABIProofEncoder(testHandlerABI, "missing"); err == nil {
+		t.Fatalf("expected missing method to fail")
+	}
+}
@@ -0,0 +1,213 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package txtrie
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/ChainSafe/chainbridge-ethereum-trie/txtrie/txgen"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	ethtrie "github.com/ethereum/go-ethereum/trie"
+)
+
+// benchmarkSizes are the block sizes, in transactions, every benchmark runs with
+var benchmarkSizes = []int{1, 100, 1000, 5000}
+
+// maxBenchmarkKeys bounds the number of distinct keys proofs are prepared for
+const maxBenchmarkKeys = 256
+
+// benchmarkBlock returns n generated transactions of all kinds and their root
+func benchmarkBlock(b *testing.B, n int) (types.Transactions, common.Hash) {
+	g, err := txgen.NewGenerator(txgen.Config{Seed: int64(n)})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	txs := make(types.Transactions, n)
+	for i := range txs {
+		if txs[i], err = g.Transaction(txgen.AllKinds[i%len(txgen.AllKinds)]); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return txs, types.DeriveSha(txs, new(ethtrie.Trie))
+}
+
+// benchmarkKeys returns up to maxBenchmarkKeys keys spread over a block of n transactions
+func benchmarkKeys(b *testing.B, n int) [][]byte {
+	step := 1
+	if n > maxBenchmarkKeys {
+		step = n / maxBenchmarkKeys
+	}
+
+	var keys [][]byte
+	for i := 0; i < n; i += step {
+		key, err := rlp.EncodeToBytes(uint(i))
+		if err != nil {
+			b.Fatal(err)
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// benchmarkTries returns a TxTries holding a block of n transactions, the block root and keys into it
+func benchmarkTries(b *testing.B, n int, compact bool) (*TxTries, common.Hash, [][]byte) {
+	txs, root := benchmarkBlock(b, n)
+	txTries := NewTxTries()
+	if compact {
+		txTries.EnableCompactStorage()
+	}
+	if err := txTries.CreateNewTrie(root, txs); err != nil {
+		b.Fatal(err)
+	}
+	return txTries, root, benchmarkKeys(b, n)
+}
+
+// runSizes runs bench as a sub-benchmark for every block size
+func runSizes(b *testing.B, bench func(b *testing.B, n int)) {
+	for _, n := range benchmarkSizes {
+		n := n
+		b.Run(fmt.Sprintf("txs=%d", n), func(b *testing.B) {
+			bench(b, n)
+		})
+	}
+}
+
+func BenchmarkCreateNewTrie(b *testing.B) {
+	runSizes(b, func(b *testing.B, n int) {
+		txs, root := benchmarkBlock(b, n)
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if err := NewTxTries().CreateNewTrie(root, txs); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkRetrieveProof(b *testing.B) {
+	runSizes(b, func(b *testing.B, n int) {
+		txTries, root, keys := benchmarkTries(b, n, false)
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := txTries.RetrieveProof(root, keys[i%len(keys)]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkRetrieveEncodedProof(b *testing.B) {
+	runSizes(b, func(b *testing.B, n int) {
+		txTries, root, keys := benchmarkTries(b, n, false)
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := txTries.RetrieveEncodedProof(root, keys[i%len(keys)]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkVerifyProof(b *testing.B) {
+	runSizes(b, func(b *testing.B, n int) {
+		txTries, root, keys := benchmarkTries(b, n, false)
+
+		proofs := make([]*ProofDatabase, len(keys))
+		for i, key := range keys {
+			proofDb, err := txTries.RetrieveProof(root, key)
+			if err != nil {
+				b.Fatal(err)
+			}
+			proofs[i] = proofDb
+		}
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			j := i % len(keys)
+			if exists, err := VerifyProof(root, keys[j], proofs[j]); err != nil || !exists {
+				b.Fatalf("not able to verify proof: %v", err)
+			}
+		}
+	})
+}
+
+func BenchmarkCreateNewTrieCompact(b *testing.B) {
+	runSizes(b, func(b *testing.B, n int) {
+		txs, root := benchmarkBlock(b, n)
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			txTries := NewTxTries()
+			txTries.EnableCompactStorage()
+			if err := txTries.CreateNewTrie(root, txs); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkRetrieveProofCompact(b *testing.B) {
+	runSizes(b, func(b *testing.B, n int) {
+		txTries, root, keys := benchmarkTries(b, n, true)
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := txTries.RetrieveProof(root, keys[i%len(keys)]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// BenchmarkTrieHeap reports the heap a stored trie keeps alive in heap-bytes/trie, for full and compact
+// storage. Every iteration collects garbage twice, so ns/op is not meaningful.
+func BenchmarkTrieHeap(b *testing.B) {
+	for _, compact := range []bool{false, true} {
+		compact := compact
+		mode := "full"
+		if compact {
+			mode = "compact"
+		}
+		b.Run(mode, func(b *testing.B) {
+			runSizes(b, func(b *testing.B, n int) {
+				txs, root := benchmarkBlock(b, n)
+
+				var heap int64
+				var before, after runtime.MemStats
+				for i := 0; i < b.N; i++ {
+					runtime.GC()
+					runtime.ReadMemStats(&before)
+
+					txTries := NewTxTries()
+					if compact {
+						txTries.EnableCompactStorage()
+					}
+					if err := txTries.CreateNewTrie(root, txs); err != nil {
+						b.Fatal(err)
+					}
+
+					runtime.GC()
+					runtime.ReadMemStats(&after)
+					runtime.KeepAlive(txTries)
+					heap += int64(after.HeapAlloc) - int64(before.HeapAlloc)
+				}
+				b.ReportMetric(float64(heap)/float64(b.N), "heap-bytes/trie")
+			})
+		})
+	}
+}
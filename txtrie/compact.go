@@ -0,0 +1,122 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package txtrie
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	ethtrie "github.com/ethereum/go-ethereum/trie"
+)
+
+// storedTrie is a validated trie TxTries builds proofs from, either an *ethtrie.Trie or a *compactTrie
+type storedTrie interface {
+	Hash() common.Hash
+	Prove(key []byte, fromLevel uint, proofDb ethdb.KeyValueWriter) error
+}
+
+// EnableCompactStorage makes TxTries t store the tries it creates from now on as compact immutable node
+// stores instead of full tries. Compact tries only keep the encoded nodes proofs are built from.
+func (t *TxTries) EnableCompactStorage() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.compact = true
+}
+
+// compactTrie is an immutable trie holding the encoded nodes of a validated trie in a single buffer.
+// The index costs 36 bytes per node: the hash and the end offset of the encoding.
+type compactTrie struct {
+	root    common.Hash
+	hashes  []common.Hash // sorted hashes of the nodes
+	offsets []uint32      // end of the encoding of the node at the same index in data
+	data    []byte        // encodings of the nodes, in the order of hashes
+}
+
+// newCompactTrie commits trie, which must be backed by the otherwise unused database db, and copies its
+// nodes into a compact trie with root root
+func newCompactTrie(root common.Hash, trie *ethtrie.Trie, db *ethtrie.Database) (*compactTrie, error) {
+	if _, err := trie.Commit(nil); err != nil {
+		return nil, err
+	}
+
+	hashes := db.Nodes()
+	sort.Slice(hashes, func(i, j int) bool {
+		return bytes.Compare(hashes[i][:], hashes[j][:]) < 0
+	})
+
+	var data []byte
+	offsets := make([]uint32, len(hashes))
+	for i, hash := range hashes {
+		buf, err := db.Node(hash)
+		if err != nil {
+			return nil, err
+		}
+		if len(data)+len(buf) > math.MaxUint32 {
+			return nil, errors.New("trie too large to store compactly")
+		}
+		data = append(data, buf...)
+		offsets[i] = uint32(len(data))
+	}
+
+	// drop the spare capacity left by append
+	return &compactTrie{root: root, hashes: hashes, offsets: offsets, data: append([]byte(nil), data...)}, nil
+}
+
+// Hash returns the root of the compact trie
+func (c *compactTrie) Hash() common.Hash {
+	return c.root
+}
+
+// Prove writes the hashed nodes on the path of key to proofDb, like ethtrie.Trie.Prove does. Compact
+// tries only prove from the root, so fromLevel must be 0.
+func (c *compactTrie) Prove(key []byte, fromLevel uint, proofDb ethdb.KeyValueWriter) error {
+	if fromLevel != 0 {
+		return errors.New("compact tries only prove from the root")
+	}
+	if c.root == emptyRoot {
+		return nil
+	}
+
+	_, err := walkPath(c.root, key, c.lookup, func(w *walkedNode) error {
+		if w.hash == nil {
+			return nil
+		}
+		return proofDb.Put(w.hash, w.enc)
+	})
+	return err
+}
+
+// lookup returns the encoding of the node with hash hash, or nil if the trie doesn't hold it
+func (c *compactTrie) lookup(hash common.Hash) ([]byte, error) {
+	i := sort.Search(len(c.hashes), func(i int) bool {
+		return bytes.Compare(c.hashes[i][:], hash[:]) >= 0
+	})
+	if i == len(c.hashes) || c.hashes[i] != hash {
+		return nil, nil
+	}
+	return c.encoding(i), nil
+}
+
+// encoding returns the encoding of the node at index i
+func (c *compactTrie) encoding(i int) []byte {
+	var start uint32
+	if i > 0 {
+		start = c.offsets[i-1]
+	}
+	return c.data[start:c.offsets[i]]
+}
+
+// proofDatabase returns a database holding all nodes of the compact trie
+func (c *compactTrie) proofDatabase() *ProofDatabase {
+	nodes := NewProofDatabase()
+	for i, hash := range c.hashes {
+		nodes.put(hash[:], c.encoding(i))
+	}
+	return nodes
+}
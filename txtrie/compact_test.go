@@ -0,0 +1,161 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package txtrie
+
+import (
+	"bytes"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	ethtrie "github.com/ethereum/go-ethereum/trie"
+)
+
+// newCompareTries stores the trie of values in a full and a compact TxTries
+func newCompareTries(t *testing.T, values rawValues) (*TxTries, *TxTries, common.Hash) {
+	root := types.DeriveSha(values, new(ethtrie.Trie))
+
+	full := NewTxTries()
+	compact := NewTxTries()
+	compact.EnableCompactStorage()
+	for _, txTries := range []*TxTries{full, compact} {
+		if err := txTries.CreateNewRawTrie(root, values); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, ok := compact.txTries[root].(*compactTrie); !ok {
+		t.Fatalf("trie not stored compactly, got: %T", compact.txTries[root])
+	}
+	return full, compact, root
+}
+
+// checkSameProof checks that both TxTries return the same proof for key
+func checkSameProof(t *testing.T, full, compact *TxTries, root common.Hash, key []byte) {
+	fullProof, err := full.RetrieveProof(root, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compactProof, err := compact.RetrieveProof(root, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected, err := fullProof.sortedEntries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := compactProof.sortedEntries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(expected, got) {
+		t.Fatalf("proofs for key %x differ, expected: %x, got: %x", key, expected, got)
+	}
+
+	// proofs of empty tries can't be encoded
+	fullEncoded, fullErr := full.RetrieveEncodedProof(root, key)
+	compactEncoded, compactErr := compact.RetrieveEncodedProof(root, key)
+	if (fullErr == nil) != (compactErr == nil) {
+		t.Fatalf("encoding errors for key %x differ, expected: %v, got: %v", key, fullErr, compactErr)
+	}
+	if !bytes.Equal(fullEncoded, compactEncoded) {
+		t.Fatalf("encoded proofs for key %x differ, expected: %x, got: %x", key, fullEncoded, compactEncoded)
+	}
+}
+
+func TestCompactTrieProofs(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	for _, count := range []int{0, 1, 2, 16, 17, 128, 129, 1000} {
+		for _, maxValueSize := range []int{1, 8, 200} {
+			values := make(rawValues, count)
+			for i := range values {
+				values[i] = make([]byte, rng.Intn(maxValueSize)+1)
+				rng.Read(values[i])
+			}
+			full, compact, root := newCompareTries(t, values)
+
+			// absent keys prove the divergence from the trie
+			for i := 0; i < count+20; i++ {
+				key, err := rlp.EncodeToBytes(uint(i))
+				if err != nil {
+					t.Fatal(err)
+				}
+				checkSameProof(t, full, compact, root, key)
+			}
+			checkSameProof(t, full, compact, root, []byte{})
+			checkSameProof(t, full, compact, root, []byte{0xff, 0xff, 0xff})
+
+			fullStats, err := full.Stats()
+			if err != nil {
+				t.Fatal(err)
+			}
+			compactStats, err := compact.Stats()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(fullStats, compactStats) {
+				t.Fatalf("stats differ for %d values, expected: %+v, got: %+v", count, fullStats, compactStats)
+			}
+		}
+	}
+}
+
+func TestCompactTrieProveFromLevel(t *testing.T) {
+	_, compact, root := newCompareTries(t, rawValues{[]byte("value")})
+	if err := compact.txTries[root].Prove([]byte{0x80}, 1, NewProofDatabase()); err == nil {
+		t.Fatal("expected an error when proving from a level other than the root")
+	}
+}
+
+func TestCompactTrieIndex(t *testing.T) {
+	value := bytes.Repeat([]byte{0xaa}, 100)
+	other := bytes.Repeat([]byte{0xbb}, 100)
+	values := rawValues{value, other, value, value}
+	full, compact, root := newCompareTries(t, values)
+
+	trie := compact.txTries[root].(*compactTrie)
+	if len(trie.offsets) != len(trie.hashes) || int(trie.offsets[len(trie.offsets)-1]) != len(trie.data) {
+		t.Fatalf("unexpected index of %d nodes, %d offsets and %d data bytes", len(trie.hashes), len(trie.offsets), len(trie.data))
+	}
+	for i, hash := range trie.hashes {
+		if enc := trie.encoding(i); crypto.Keccak256Hash(enc) != hash {
+			t.Fatalf("encoding of node %x does not match its hash: %x", hash, enc)
+		}
+	}
+	for i := range values {
+		key, err := rlp.EncodeToBytes(uint(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkSameProof(t, full, compact, root, key)
+	}
+}
+
+func TestCompactTrieExistingTries(t *testing.T) {
+	vals := GetTransactions1()
+	root, err := computeEthReferenceTrieHash(vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	txTries := NewTxTries()
+	if err := addTrie(txTries, root, vals); err != nil {
+		t.Fatal(err)
+	}
+	txTries.EnableCompactStorage()
+
+	if _, ok := txTries.txTries[root].(*ethtrie.Trie); !ok {
+		t.Fatalf("existing trie should keep its storage, got: %T", txTries.txTries[root])
+	}
+	if err := addTrie(txTries, root, vals); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := txTries.txTries[root].(*compactTrie); !ok {
+		t.Fatalf("new trie should be stored compactly, got: %T", txTries.txTries[root])
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package txtrie
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// DepositChainReader is the subset of go-ethereum's ethclient needed to prove deposits
+type DepositChainReader interface {
+	BlockReader
+	HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error)
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+}
+
+// RawReceiptsReader returns the consensus encoding of the receipts of a block, which are the values of
+// its receipt trie. This version of go-ethereum encodes every types.Receipt as a legacy receipt, so
+// blocks with typed transactions can only be proven by a DepositChainReader that also implements it.
+type RawReceiptsReader interface {
+	RawReceipts(ctx context.Context, blockHash common.Hash) ([][]byte, error)
+}
+
+// RPCDepositChainReader is a DepositChainReader that reads raw receipts through the debug namespace of a node
+type RPCDepositChainReader struct {
+	*ethclient.Client
+	rpc *rpc.Client
+}
+
+// NewRPCDepositChainReader creates a new RPCDepositChainReader using client
+func NewRPCDepositChainReader(client *rpc.Client) *RPCDepositChainReader {
+	return &RPCDepositChainReader{Client: ethclient.NewClient(client), rpc: client}
+}
+
+// RawReceipts returns the consensus encoding of the receipts of the block with hash blockHash
+func (r *RPCDepositChainReader) RawReceipts(ctx context.Context, blockHash common.Hash) ([][]byte, error) {
+	var receipts []hexutil.Bytes
+	if err := r.rpc.CallContext(ctx, &receipts, "debug_getRawReceipts", blockHash); err != nil {
+		return nil, fmt.Errorf("debug_getRawReceipts: %v", err)
+	}
+
+	values := make([][]byte, len(receipts))
+	for i, receipt := range receipts {
+		values[i] = receipt
+	}
+	return values, nil
+}
+
+// DepositProof is the payload submitted to the destination chain handler to prove a deposit
+type DepositProof struct {
+	BlockHash    common.Hash
+	BlockNumber  uint64
+	TxRoot       common.Hash
+	ReceiptRoot  common.Hash
+	TxIndex      uint
+	Key          []byte // rlp encoded transaction index, the path in both tries
+	TxProof      []byte // encoded proof of the transaction in the transaction trie
+	ReceiptProof []byte // encoded proof of the receipt in the receipt trie
+}
+
+// Encode rlp encodes a DepositProof for submission
+func (p *DepositProof) Encode() ([]byte, error) {
+	return rlp.EncodeToBytes(p)
+}
+
+// DepositProver produces DepositProofs for logs emitted on the source chain
+type DepositProver struct {
+	client  DepositChainReader
+	fetcher *Fetcher
+	txTries *TxTries
+}
+
+// NewDepositProver creates a new DepositProver storing the tries it needs in txTries
+func NewDepositProver(client DepositChainReader, txTries *TxTries) *DepositProver {
+	return &DepositProver{
+		client:  client,
+		fetcher: NewFetcher(client, txTries),
+		txTries: txTries,
+	}
+}
+
+// Fetcher returns the Fetcher used by the DepositProver so its retry behaviour can be adjusted
+func (p *DepositProver) Fetcher() *Fetcher {
+	return p.fetcher
+}
+
+// ProveDeposit produces the transaction and receipt proofs of the transaction that emitted log
+func (p *DepositProver) ProveDeposit(ctx context.Context, log types.Log) (*DepositProof, error) {
+	if log.Removed {
+		return nil, errors.New("log was removed by a chain reorganisation")
+	}
+
+	header, err := p.client.HeaderByHash(ctx, log.BlockHash)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.ensureTries(ctx, header); err != nil {
+		return nil, err
+	}
+
+	key, err := rlp.EncodeToBytes(log.TxIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	txProof, err := p.proveValue(header.TxHash, key)
+	if err != nil {
+		return nil, fmt.Errorf("transaction %d of block %x: %v", log.TxIndex, log.BlockHash, err)
+	}
+
+	receiptProof, err := p.proveValue(header.ReceiptHash, key)
+	if err != nil {
+		return nil, fmt.Errorf("receipt %d of block %x: %v", log.TxIndex, log.BlockHash, err)
+	}
+
+	return &DepositProof{
+		BlockHash:    log.BlockHash,
+		BlockNumber:  header.Number.Uint64(),
+		TxRoot:       header.TxHash,
+		ReceiptRoot:  header.ReceiptHash,
+		TxIndex:      log.TxIndex,
+		Key:          key,
+		TxProof:      txProof,
+		ReceiptProof: receiptProof,
+	}, nil
+}
+
+// proveValue returns the encoded proof of the value at key in the trie with root root, failing if the
+// trie doesn't contain key
+func (p *DepositProver) proveValue(root common.Hash, key []byte) ([]byte, error) {
+	proofDb, err := p.txTries.RetrieveProof(root, key)
+	if err != nil {
+		return nil, err
+	}
+	value, err := verifyProof(root, key, proofDb)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, errors.New("not found in block")
+	}
+	return encodeProofDB(root, key, proofDb)
+}
+
+// ensureTries makes sure the transaction and receipt tries of the block with header are stored
+func (p *DepositProver) ensureTries(ctx context.Context, header *types.Header) error {
+	if p.txTries.hasTrie(header.TxHash) && p.txTries.hasTrie(header.ReceiptHash) {
+		return nil
+	}
+
+	block, err := p.fetcher.FetchByHash(ctx, header.Hash())
+	if err != nil {
+		return err
+	}
+
+	if p.txTries.hasTrie(header.ReceiptHash) {
+		return nil
+	}
+
+	if raw, ok := p.client.(RawReceiptsReader); ok {
+		receipts, err := raw.RawReceipts(ctx, block.Hash())
+		if err != nil {
+			return err
+		}
+		return p.txTries.CreateNewRawTrie(header.ReceiptHash, receipts)
+	}
+
+	// without raw receipts, only blocks of legacy transactions have a matching receipt root
+	receipts := make(types.Receipts, len(block.Transactions()))
+	for i, tx := range block.Transactions() {
+		receipt, err := p.client.TransactionReceipt(ctx, tx.Hash())
+		if err != nil {
+			return err
+		}
+		if receipt.BlockHash != block.Hash() {
+			return fmt.Errorf("receipt of transaction %x belongs to block %x, expected %x", tx.Hash(), receipt.BlockHash, block.Hash())
+		}
+		receipts[i] = receipt
+	}
+
+	return p.txTries.CreateNewReceiptTrie(header.ReceiptHash, receipts)
+}
@@ -0,0 +1,205 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package txtrie
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// logEmitterCode is init code that emits a single LOG1 with topic 0xff and deploys nothing
+var logEmitterCode = common.FromHex("0x60ff60006000a100")
+
+// emitTestLog commits a block containing a contract creation whose init code emits a log
+func emitTestLog(t *testing.T, backend *backends.SimulatedBackend, nonce uint64) *types.Receipt {
+	tx := types.NewContractCreation(nonce, big.NewInt(0), 100000, big.NewInt(1), logEmitterCode)
+	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(big.NewInt(1337)), testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := backend.SendTransaction(context.Background(), signedTx); err != nil {
+		t.Fatal(err)
+	}
+	backend.Commit()
+
+	receipt, err := backend.TransactionReceipt(context.Background(), signedTx.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(receipt.Logs) != 1 {
+		t.Fatalf("unexpected number of logs, expected: %d, got: %d", 1, len(receipt.Logs))
+	}
+	return receipt
+}
+
+func TestProveDeposit(t *testing.T) {
+	backend := newTestBackend(t, 3)
+	defer backend.Close()
+
+	receipt := emitTestLog(t, backend, 3)
+	log := *receipt.Logs[0]
+
+	txTries := NewTxTries()
+	prover := NewDepositProver(backend, txTries)
+
+	depositProof, err := prover.ProveDeposit(context.Background(), log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block, err := backend.BlockByHash(context.Background(), log.BlockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if depositProof.TxRoot != block.TxHash() || depositProof.ReceiptRoot != block.ReceiptHash() {
+		t.Fatalf("deposit proof has wrong roots")
+	}
+
+	txProofDb, err := txTries.RetrieveProof(depositProof.TxRoot, depositProof.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txValue, err := verifyProof(depositProof.TxRoot, depositProof.Key, txProofDb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedTx, err := rlp.EncodeToBytes(block.Transactions()[log.TxIndex])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(txValue, expectedTx) {
+		t.Fatalf("proven transaction does not match, expected: %x, got: %x", expectedTx, txValue)
+	}
+
+	receiptProofDb, err := txTries.RetrieveProof(depositProof.ReceiptRoot, depositProof.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	receiptValue, err := verifyProof(depositProof.ReceiptRoot, depositProof.Key, receiptProofDb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedReceipt, err := rlp.EncodeToBytes(receipt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(receiptValue, expectedReceipt) {
+		t.Fatalf("proven receipt does not match, expected: %x, got: %x", expectedReceipt, receiptValue)
+	}
+
+	encoded, err := depositProof.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded DepositProof
+	if err := rlp.DecodeBytes(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decoded.ReceiptProof, depositProof.ReceiptProof) {
+		t.Fatalf("decoded deposit proof does not match")
+	}
+}
+
+func TestProveDepositRemovedLog(t *testing.T) {
+	backend := newTestBackend(t, 0)
+	defer backend.Close()
+
+	receipt := emitTestLog(t, backend, 0)
+	log := *receipt.Logs[0]
+	log.Removed = true
+
+	prover := NewDepositProver(backend, NewTxTries())
+	if _, err := prover.ProveDeposit(context.Background(), log); err == nil {
+		t.Fatalf("expected proving a removed log to fail")
+	}
+}
+
+func TestProveDepositMissingTransaction(t *testing.T) {
+	backend := newTestBackend(t, 0)
+	defer backend.Close()
+
+	receipt := emitTestLog(t, backend, 0)
+	log := *receipt.Logs[0]
+	log.TxIndex = 5
+
+	prover := NewDepositProver(backend, NewTxTries())
+	if depositProof, err := prover.ProveDeposit(context.Background(), log); err == nil {
+		t.Fatalf("expected proving a transaction index out of range to fail, got: %+v", depositProof)
+	}
+}
+
+// testDebugAPI serves debug_getRawReceipts from a simulated backend
+type testDebugAPI struct {
+	backend *backends.SimulatedBackend
+	calls   int
+}
+
+func (api *testDebugAPI) GetRawReceipts(ctx context.Context, hash common.Hash) ([]hexutil.Bytes, error) {
+	api.calls++
+	block, err := api.backend.BlockByHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	receipts := make([]hexutil.Bytes, len(block.Transactions()))
+	for i, tx := range block.Transactions() {
+		receipt, err := api.backend.TransactionReceipt(ctx, tx.Hash())
+		if err != nil {
+			return nil, err
+		}
+		if receipts[i], err = rlp.EncodeToBytes(receipt); err != nil {
+			return nil, err
+		}
+	}
+	return receipts, nil
+}
+
+// rawReceiptsBackend is a simulated backend reading raw receipts from a node
+type rawReceiptsBackend struct {
+	*backends.SimulatedBackend
+	raw RawReceiptsReader
+}
+
+func (b *rawReceiptsBackend) RawReceipts(ctx context.Context, blockHash common.Hash) ([][]byte, error) {
+	return b.raw.RawReceipts(ctx, blockHash)
+}
+
+func TestProveDepositRawReceipts(t *testing.T) {
+	backend := newTestBackend(t, 0)
+	defer backend.Close()
+
+	receipt := emitTestLog(t, backend, 0)
+	log := *receipt.Logs[0]
+
+	api := &testDebugAPI{backend: backend}
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("debug", api); err != nil {
+		t.Fatal(err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	txTries := NewTxTries()
+	prover := NewDepositProver(&rawReceiptsBackend{backend, NewRPCDepositChainReader(client)}, txTries)
+	depositProof, err := prover.ProveDeposit(context.Background(), log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if api.calls != 1 {
+		t.Fatalf("unexpected number of raw receipt requests, expected: %d, got: %d", 1, api.calls)
+	}
+	if !txTries.hasTrie(depositProof.ReceiptRoot) {
+		t.Fatalf("receipt trie was not stored")
+	}
+}
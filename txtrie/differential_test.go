@@ -0,0 +1,121 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package txtrie
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+	ethtrie "github.com/ethereum/go-ethereum/trie"
+)
+
+// randomKeyTrie builds a trie of count random keys of 1 to 8 bytes, so keys share prefixes
+// and diverge at every depth, with values of up to maxValueSize bytes
+func randomKeyTrie(t *testing.T, rng *rand.Rand, count, maxValueSize int) (*ethtrie.Trie, [][]byte) {
+	trie, err := ethtrie.New(emptyRoot, ethtrie.NewDatabase(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var keys [][]byte
+	for i := 0; i < count; i++ {
+		key := make([]byte, 1+rng.Intn(8))
+		rng.Read(key)
+		// short keys make prefixes of other keys more likely
+		if i > 0 && rng.Intn(4) == 0 {
+			prefix := keys[rng.Intn(len(keys))]
+			key = append(common.CopyBytes(prefix[:1+rng.Intn(len(prefix))]), key[:rng.Intn(len(key))]...)
+		}
+		value := make([]byte, 1+rng.Intn(maxValueSize))
+		rng.Read(value)
+
+		trie.Update(key, value)
+		keys = append(keys, key)
+	}
+	trie.Hash()
+	return trie, keys
+}
+
+// checkAgainstUpstream asserts verifyProof and go-ethereum's trie.VerifyProof agree on the proof
+func checkAgainstUpstream(t *testing.T, root common.Hash, key []byte, proofDb *ProofDatabase) {
+	value, err := verifyProof(root, key, proofDb)
+	expected, expectedErr := ethtrie.VerifyProof(root, key, proofDb)
+
+	if (err == nil) != (expectedErr == nil) {
+		t.Fatalf("verification errors differ, root: %x, key: %x, expected: %v, got: %v", root, key, expectedErr, err)
+	}
+	if !bytes.Equal(value, expected) {
+		t.Fatalf("verified values differ, root: %x, key: %x, expected: %x, got: %x", root, key, expected, value)
+	}
+}
+
+// checkProofVariants checks the proof of key in trie, and variants of it that must fail or prove absence
+func checkProofVariants(t *testing.T, rng *rand.Rand, trie storedTrie, key []byte, otherKey []byte) {
+	root := trie.Hash()
+	proofDb, err := retrieveProof(trie, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkAgainstUpstream(t, root, key, proofDb)
+	checkAgainstUpstream(t, root, otherKey, proofDb)
+	checkAgainstUpstream(t, common.Hash{0x01}, key, proofDb)
+
+	entries, err := proofDb.sortedEntries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := entries[rng.Intn(len(entries))]
+
+	// corrupt a node while keeping it stored under its original hash
+	tampered := common.CopyBytes(entry.Value)
+	tampered[rng.Intn(len(tampered))] ^= byte(rng.Intn(255) + 1)
+	if err := proofDb.Put(entry.Key, tampered); err != nil {
+		t.Fatal(err)
+	}
+	checkAgainstUpstream(t, root, key, proofDb)
+
+	if err := proofDb.Delete(entry.Key); err != nil {
+		t.Fatal(err)
+	}
+	checkAgainstUpstream(t, root, key, proofDb)
+}
+
+func TestVerifyProofMatchesUpstreamRandomKeys(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, count := range []int{1, 2, 3, 16, 50, 500} {
+		for _, maxValueSize := range []int{4, 32, 300} {
+			trie, keys := randomKeyTrie(t, rng, count, maxValueSize)
+			for _, key := range keys {
+				otherKey := make([]byte, 1+rng.Intn(8))
+				rng.Read(otherKey)
+				checkProofVariants(t, rng, trie, key, otherKey)
+			}
+		}
+	}
+}
+
+func TestVerifyProofMatchesUpstreamIndexKeys(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for _, count := range []int{1, 17, 128, 129, 1000, 5000} {
+		for _, maxValueSize := range []int{8, 200} {
+			txTries, root := newRandomTxTries(t, rng, count, maxValueSize)
+			trie := txTries.txTries[root]
+
+			for i := 0; i < count; i++ {
+				key, err := rlp.EncodeToBytes(uint(i))
+				if err != nil {
+					t.Fatal(err)
+				}
+				otherKey, err := rlp.EncodeToBytes(uint(rng.Intn(2 * count)))
+				if err != nil {
+					t.Fatal(err)
+				}
+				checkProofVariants(t, rng, trie, key, otherKey)
+			}
+		}
+	}
+}
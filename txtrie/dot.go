@@ -0,0 +1,169 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package txtrie
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/ChainSafe/chainbridge-ethereum-trie/txtrie/trienode"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	ethtrie "github.com/ethereum/go-ethereum/trie"
+)
+
+// dotHashPrefix is the number of hash bytes shown in node labels
+const dotHashPrefix = 4
+
+// WriteDOT writes the trie with root root as a Graphviz DOT graph to w. If highlightKey is not nil
+// the nodes and edges on its path are highlighted.
+func (t *TxTries) WriteDOT(w io.Writer, root common.Hash, highlightKey []byte) error {
+	trie, _ := t.lookupTrie(root)
+	if trie == nil {
+		return errTrieNotFound
+	}
+
+	nodes, err := trieNodes(trie)
+	if err != nil {
+		return err
+	}
+	return writeDOT(w, root, nodes, highlightKey)
+}
+
+// trieNodes collects the hashed nodes of trie, full tries by proving every key they hold
+func trieNodes(stored storedTrie) (*ProofDatabase, error) {
+	var trie *ethtrie.Trie
+	switch st := stored.(type) {
+	case *ethtrie.Trie:
+		trie = st
+	case *compactTrie:
+		return st.proofDatabase(), nil
+	default:
+		return nil, fmt.Errorf("%T: invalid stored trie", stored)
+	}
+
+	nodes := NewProofDatabase()
+	it := trie.NodeIterator(nil)
+	for it.Next(true) {
+		if !it.Leaf() {
+			continue
+		}
+		if err := trie.Prove(it.LeafKey(), 0, nodes); err != nil {
+			return nil, err
+		}
+	}
+	if it.Error() != nil {
+		return nil, it.Error()
+	}
+	return nodes, nil
+}
+
+// writeDOT writes the trie with root root, whose hashed nodes are stored in nodes, as a DOT graph
+func writeDOT(w io.Writer, root common.Hash, nodes ethdb.KeyValueReader, highlightKey []byte) error {
+	d := &dotWriter{nodes: dbLookup(nodes)}
+	d.buf.WriteString("digraph trie {\n\tnode [fontname=\"monospace\"];\n")
+
+	if root == emptyRoot {
+		d.buf.WriteString("\tn0 [shape=plaintext, label=\"empty trie\"];\n")
+	} else {
+		var nibbles []byte
+		if highlightKey != nil {
+			nibbles = trienode.KeybytesToHex(highlightKey)
+		}
+		if _, err := d.node(hashNode(root[:]), nibbles, highlightKey != nil); err != nil {
+			return err
+		}
+	}
+
+	d.buf.WriteString("}\n")
+	_, err := w.Write(d.buf.Bytes())
+	return err
+}
+
+type dotWriter struct {
+	nodes nodeLookup
+	buf   bytes.Buffer
+	count int
+}
+
+// node writes n and its children, nibbles is the rest of the highlighted key if onPath is set.
+// It returns the id of the written node.
+func (d *dotWriter) node(n node, nibbles []byte, onPath bool) (string, error) {
+	var hash hashNode
+	if h, ok := n.(hashNode); ok {
+		decoded, _, err := resolveNode(d.nodes, h)
+		if err != nil {
+			return "", err
+		}
+		if decoded == nil {
+			return "", fmt.Errorf("trie node %x missing", []byte(h))
+		}
+		hash, n = h, decoded
+	}
+
+	id := fmt.Sprintf("n%d", d.count)
+	d.count++
+
+	ref := "embedded"
+	if hash != nil {
+		ref = fmt.Sprintf("%x", []byte(hash[:dotHashPrefix]))
+	}
+
+	switch n := n.(type) {
+	case *fullNode:
+		d.writeNode(id, "box", fmt.Sprintf("branch\\n%s", ref), onPath)
+		for i, child := range &n.Children {
+			if child == nil {
+				continue
+			}
+			childOnPath := onPath && len(nibbles) > 0 && int(nibbles[0]) == i
+			var rest []byte
+			if childOnPath {
+				rest = nibbles[1:]
+			}
+			childID, err := d.node(child, rest, childOnPath)
+			if err != nil {
+				return "", err
+			}
+			d.writeEdge(id, childID, nibbleString([]byte{byte(i)}), childOnPath)
+		}
+	case *shortNode:
+		matched := onPath && len(nibbles) >= len(n.Key) && bytes.Equal(n.Key, nibbles[:len(n.Key)])
+		if value, ok := n.Val.(valueNode); ok {
+			d.writeNode(id, "ellipse", fmt.Sprintf("leaf [%s]\\n%s\\n%d bytes", nibbleString(n.Key), ref, len(value)), onPath)
+			break
+		}
+		d.writeNode(id, "hexagon", fmt.Sprintf("extension\\n%s", ref), onPath)
+		var rest []byte
+		if matched {
+			rest = nibbles[len(n.Key):]
+		}
+		childID, err := d.node(n.Val, rest, matched)
+		if err != nil {
+			return "", err
+		}
+		d.writeEdge(id, childID, nibbleString(n.Key), matched)
+	case valueNode:
+		d.writeNode(id, "plaintext", fmt.Sprintf("value\\n%d bytes", len(n)), onPath)
+	default:
+		return "", fmt.Errorf("%T: invalid node", n)
+	}
+	return id, nil
+}
+
+func (d *dotWriter) writeNode(id string, shape string, label string, highlight bool) {
+	fmt.Fprintf(&d.buf, "\t%s [shape=%s, label=\"%s\"%s];\n", id, shape, label, dotHighlight(highlight))
+}
+
+func (d *dotWriter) writeEdge(from string, to string, label string, highlight bool) {
+	fmt.Fprintf(&d.buf, "\t%s -> %s [label=\"%s\"%s];\n", from, to, label, dotHighlight(highlight))
+}
+
+func dotHighlight(highlight bool) string {
+	if highlight {
+		return ", color=red, penwidth=2"
+	}
+	return ""
+}
@@ -0,0 +1,88 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package txtrie
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+func TestWriteDOT(t *testing.T) {
+	vals := GetTransactions2()
+	expectedRoot, err := computeEthReferenceTrieHash(vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	txTries := NewTxTries()
+	err = addTrie(txTries, expectedRoot, vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := txTries.WriteDOT(&buf, expectedRoot, nil); err != nil {
+		t.Fatal(err)
+	}
+	graph := buf.String()
+
+	if !strings.HasPrefix(graph, "digraph trie {") || !strings.HasSuffix(graph, "}\n") {
+		t.Fatalf("output is not a DOT graph:\n%s", graph)
+	}
+	if leaves := strings.Count(graph, "label=\"leaf"); leaves != len(vals) {
+		t.Fatalf("unexpected number of leaves, expected: %d, got: %d", len(vals), leaves)
+	}
+	if !strings.Contains(graph, "branch\\n"+expectedRoot.Hex()[2:2+2*dotHashPrefix]) {
+		t.Fatalf("root node missing from graph:\n%s", graph)
+	}
+	if strings.Contains(graph, "color=red") {
+		t.Fatalf("unexpected highlighting without key:\n%s", graph)
+	}
+
+	key, err := rlp.EncodeToBytes(uint(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	proofDb, err := txTries.RetrieveProof(expectedRoot, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, err := TraceProof(expectedRoot, key, proofDb)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf.Reset()
+	if err := txTries.WriteDOT(&buf, expectedRoot, key); err != nil {
+		t.Fatal(err)
+	}
+	// every node on the path and the edges between them
+	expected := 2*len(path.Steps) - 1
+	if highlighted := strings.Count(buf.String(), "color=red"); highlighted != expected {
+		t.Fatalf("unexpected number of highlighted elements, expected: %d, got: %d", expected, highlighted)
+	}
+}
+
+func TestWriteDOTEmptyTrie(t *testing.T) {
+	txTries := NewTxTries()
+	err := addTrie(txTries, emptyRoot, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := txTries.WriteDOT(&buf, emptyRoot, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "empty trie") {
+		t.Fatalf("unexpected graph for empty trie:\n%s", buf.String())
+	}
+
+	if err := txTries.WriteDOT(&buf, emptyHash, nil); err != errTrieNotFound {
+		t.Fatalf("unexpected error for unknown root, expected: %v, got: %v", errTrieNotFound, err)
+	}
+}
@@ -0,0 +1,242 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package txtrie
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ChainSafe/chainbridge-ethereum-trie/txtrie/trienode"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// VerifyEncodedProof verifies a proof produced by RetrieveEncodedProof on path key against the
+// provided root. It performs the same steps as the TxProofVerifier contract: every node is
+// re-encoded in its canonical form and checked against the hash referencing it.
+func VerifyEncodedProof(root common.Hash, key []byte, encodedProof []byte) (bool, error) {
+	value, err := verifyEncodedProof(root, key, encodedProof)
+	if err != nil {
+		return false, err
+	}
+
+	return value != nil, nil
+}
+
+func verifyEncodedProof(rootHash common.Hash, key []byte, encodedProof []byte) (value []byte, err error) {
+	proofNodes, err := decodeEncodedProof(encodedProof)
+	if err != nil {
+		return nil, err
+	}
+
+	key = trienode.KeybytesToHex(key)
+	wantHash := rootHash
+	for i := 0; ; i++ {
+		if i >= len(proofNodes) {
+			return nil, fmt.Errorf("proof node %d (hash %064x) missing", i, wantHash)
+		}
+		n := proofNodes[i]
+
+		enc, err := encodeNode(n)
+		if err != nil {
+			return nil, fmt.Errorf("bad proof node %d: %v", i, err)
+		}
+		if hash := crypto.Keccak256Hash(enc); hash != wantHash {
+			return nil, fmt.Errorf("proof node %d hash mismatch, expected %x, got %x", i, wantHash, hash)
+		}
+
+		keyrest, cld, err := get(n, key, true)
+		if err != nil {
+			return nil, fmt.Errorf("bad proof node %d: %v", i, err)
+		}
+		switch cld := cld.(type) {
+		case nil:
+			// The trie doesn't contain the key.
+			return nil, nil
+		case hashNode:
+			key = keyrest
+			copy(wantHash[:], cld)
+		case valueNode:
+			return cld, nil
+		}
+	}
+}
+
+// decodeEncodedProof parses a proof in the format produced by proof.EncodeRLP
+func decodeEncodedProof(buf []byte) (proof, error) {
+	elems, rest, err := rlp.SplitList(buf)
+	if err != nil {
+		return nil, fmt.Errorf("decode error: %v", err)
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("trailing bytes after proof")
+	}
+
+	var proofNodes proof
+	for i := 0; len(elems) > 0; i++ {
+		kind, _, rest, err := rlp.Split(elems)
+		if err != nil {
+			return nil, fmt.Errorf("bad proof node %d: %v", i, err)
+		}
+		if kind != rlp.List {
+			return nil, fmt.Errorf("bad proof node %d: not a list", i)
+		}
+		n, err := decodeEncodedNode(elems[:len(elems)-len(rest)])
+		if err != nil {
+			return nil, fmt.Errorf("bad proof node %d: %v", i, err)
+		}
+		proofNodes = append(proofNodes, n)
+		elems = rest
+	}
+
+	return proofNodes, nil
+}
+
+// decodeEncodedNode parses a node of an encoded proof. Unlike the canonical encoding parsed by
+// decodeNode, short node keys are stored as nibbles and embedded nodes are in the same format.
+func decodeEncodedNode(buf []byte) (node, error) {
+	elems, _, err := rlp.SplitList(buf)
+	if err != nil {
+		return nil, fmt.Errorf("decode error: %v", err)
+	}
+	switch c, _ := rlp.CountValues(elems); c {
+	case 2:
+		n, err := decodeEncodedShort(elems)
+		return n, wrapError(err, "short")
+	case 17:
+		n, err := decodeEncodedFull(elems)
+		return n, wrapError(err, "full")
+	default:
+		return nil, fmt.Errorf("invalid number of list elements: %v", c)
+	}
+}
+
+func decodeEncodedShort(elems []byte) (node, error) {
+	key, rest, err := rlp.SplitString(elems)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateNibbles(key); err != nil {
+		return nil, err
+	}
+	key = common.CopyBytes(key)
+
+	if trienode.HasTerm(key) {
+		val, _, err := rlp.SplitString(rest)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value node: %v", err)
+		}
+		if len(val) == 0 {
+			// tries never store empty values, treat the key as absent
+			return &shortNode{Key: key}, nil
+		}
+		return &shortNode{Key: key, Val: append(valueNode{}, val...)}, nil
+	}
+
+	r, _, err := decodeEncodedRef(rest)
+	if err != nil {
+		return nil, wrapError(err, "val")
+	}
+	if r == nil {
+		return nil, errors.New("extension node without child")
+	}
+	return &shortNode{Key: key, Val: r}, nil
+}
+
+func decodeEncodedFull(elems []byte) (*fullNode, error) {
+	n := &fullNode{}
+	for i := 0; i < 16; i++ {
+		cld, rest, err := decodeEncodedRef(elems)
+		if err != nil {
+			return n, wrapError(err, fmt.Sprintf("[%d]", i))
+		}
+		n.Children[i], elems = cld, rest
+	}
+	val, _, err := rlp.SplitString(elems)
+	if err != nil {
+		return n, err
+	}
+	if len(val) > 0 {
+		n.Children[16] = append(valueNode{}, val...)
+	}
+	return n, nil
+}
+
+func decodeEncodedRef(buf []byte) (node, []byte, error) {
+	kind, val, rest, err := rlp.Split(buf)
+	if err != nil {
+		return nil, buf, err
+	}
+	switch {
+	case kind == rlp.List:
+		n, err := decodeEncodedNode(buf[:len(buf)-len(rest)])
+		return n, rest, err
+	case kind == rlp.String && len(val) == 0:
+		// empty node
+		return nil, rest, nil
+	case kind == rlp.String && len(val) == 32:
+		return append(hashNode{}, val...), rest, nil
+	default:
+		return nil, nil, fmt.Errorf("invalid RLP string size %d (want 0 or 32)", len(val))
+	}
+}
+
+// validateNibbles checks that key is a non empty nibble path with an optional terminator at the end
+func validateNibbles(key []byte) error {
+	if len(key) == 0 {
+		return errors.New("empty short node key")
+	}
+	for i, nibble := range key {
+		if nibble > 16 || (nibble == 16 && i != len(key)-1) {
+			return fmt.Errorf("invalid nibble %d at position %d", nibble, i)
+		}
+	}
+	return nil
+}
+
+// encodeNode returns the canonical RLP encoding of n, the encoding its hash is computed from
+func encodeNode(n node) ([]byte, error) {
+	switch n := n.(type) {
+	case *shortNode:
+		val, err := encodeChild(n.Val)
+		if err != nil {
+			return nil, err
+		}
+		return rlp.EncodeToBytes([]interface{}{trienode.HexToCompact(n.Key), val})
+	case *fullNode:
+		var children [17]interface{}
+		for i, child := range &n.Children {
+			enc, err := encodeChild(child)
+			if err != nil {
+				return nil, err
+			}
+			children[i] = enc
+		}
+		return rlp.EncodeToBytes(children)
+	default:
+		return nil, fmt.Errorf("%T: invalid node: %v", n, n)
+	}
+}
+
+// encodeChild returns the value a child takes in the canonical encoding of its parent
+func encodeChild(n node) (interface{}, error) {
+	switch n := n.(type) {
+	case nil:
+		return []byte{}, nil
+	case hashNode:
+		return []byte(n), nil
+	case valueNode:
+		return []byte(n), nil
+	default:
+		enc, err := encodeNode(n)
+		if err != nil {
+			return nil, err
+		}
+		if len(enc) >= hashLen {
+			return nil, fmt.Errorf("oversized embedded node (size is %d bytes, want size < %d)", len(enc), hashLen)
+		}
+		return rlp.RawValue(enc), nil
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package txtrie
+
+import (
+	"encoding/json"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// ProofEncoder converts the proof of a key between a ProofDatabase and a serialized format
+type ProofEncoder interface {
+	// Encode serializes the nodes of proofDb on the path of key in the trie with root root
+	Encode(root common.Hash, key []byte, proofDb *ProofDatabase) ([]byte, error)
+	// Decode parses a serialized proof into a ProofDatabase
+	Decode(encodedProof []byte) (*ProofDatabase, error)
+}
+
+var (
+	// NestedRLPEncoder produces the format of RetrieveEncodedProof, an RLP list of nodes with full
+	// nodes expanded inline and short node keys stored as nibbles
+	NestedRLPEncoder ProofEncoder = nestedRLPEncoder{}
+	// FlatRLPEncoder produces an RLP list of the canonical RLP encoding of every node, the layout
+	// of a Solidity bytes[] of nodes
+	FlatRLPEncoder ProofEncoder = flatRLPEncoder{}
+	// HexJSONEncoder produces a JSON array of hex encoded nodes, like the proofs returned by eth_getProof
+	HexJSONEncoder ProofEncoder = hexJSONEncoder{}
+)
+
+// RetrieveProofWithEncoder retrieves a Proof for a value at key in trie with root root serialized by encoder
+func (t *TxTries) RetrieveProofWithEncoder(root common.Hash, key []byte, encoder ProofEncoder) ([]byte, error) {
+	proofDB, err := t.RetrieveProof(root, key)
+	if err != nil {
+		return nil, err
+	}
+	return encoder.Encode(root, key, proofDB)
+}
+
+type nestedRLPEncoder struct{}
+
+func (nestedRLPEncoder) Encode(root common.Hash, key []byte, proofDb *ProofDatabase) ([]byte, error) {
+	return encodeProofDB(root, key, proofDb)
+}
+
+func (nestedRLPEncoder) Decode(encodedProof []byte) (*ProofDatabase, error) {
+	proofNodes, err := decodeEncodedProof(encodedProof)
+	if err != nil {
+		return nil, err
+	}
+
+	var nodes [][]byte
+	for _, n := range proofNodes {
+		enc, err := encodeNode(n)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, enc)
+	}
+	return nodesToProofDB(nodes)
+}
+
+type flatRLPEncoder struct{}
+
+func (flatRLPEncoder) Encode(root common.Hash, key []byte, proofDb *ProofDatabase) ([]byte, error) {
+	nodes, err := proofPathNodes(root, key, proofDb)
+	if err != nil {
+		return nil, err
+	}
+	return rlp.EncodeToBytes(nodes)
+}
+
+func (flatRLPEncoder) Decode(encodedProof []byte) (*ProofDatabase, error) {
+	var nodes [][]byte
+	if err := rlp.DecodeBytes(encodedProof, &nodes); err != nil {
+		return nil, err
+	}
+	return nodesToProofDB(nodes)
+}
+
+type hexJSONEncoder struct{}
+
+func (hexJSONEncoder) Encode(root common.Hash, key []byte, proofDb *ProofDatabase) ([]byte, error) {
+	nodes, err := proofPathNodes(root, key, proofDb)
+	if err != nil {
+		return nil, err
+	}
+
+	hexNodes := make([]hexutil.Bytes, len(nodes))
+	for i, n := range nodes {
+		hexNodes[i] = n
+	}
+	return json.Marshal(hexNodes)
+}
+
+func (hexJSONEncoder) Decode(encodedProof []byte) (*ProofDatabase, error) {
+	var hexNodes []hexutil.Bytes
+	if err := json.Unmarshal(encodedProof, &hexNodes); err != nil {
+		return nil, err
+	}
+
+	nodes := make([][]byte, len(hexNodes))
+	for i, n := range hexNodes {
+		nodes[i] = n
+	}
+	return nodesToProofDB(nodes)
+}
+
+// proofPathNodes returns the canonical encoding of the nodes on the path of key, starting at the root.
+// If the trie doesn't contain the key, the nodes proving its absence are returned.
+func proofPathNodes(rootHash common.Hash, key []byte, proofDb ethdb.KeyValueReader) ([][]byte, error) {
+	var nodes [][]byte
+	_, err := walkPath(rootHash, key, dbLookup(proofDb), func(w *walkedNode) error {
+		if w.hash != nil {
+			nodes = append(nodes, w.enc)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+// nodesToProofDB stores nodes in a new ProofDatabase keyed by their hash
+func nodesToProofDB(nodes [][]byte) (*ProofDatabase, error) {
+	proofDb := NewProofDatabase()
+	for _, n := range nodes {
+		if err := proofDb.Put(crypto.Keccak256(n), n); err != nil {
+			return nil, err
+		}
+	}
+	return proofDb, nil
+}
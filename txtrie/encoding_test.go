@@ -0,0 +1,174 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package txtrie
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+func TestProofEncodersRoundTrip(t *testing.T) {
+	vals := GetTransactions2()
+	expectedRoot, err := computeEthReferenceTrieHash(vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	txTries := NewTxTries()
+	err = addTrie(txTries, expectedRoot, vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encoders := map[string]ProofEncoder{
+		"nested rlp": NestedRLPEncoder,
+		"flat rlp":   FlatRLPEncoder,
+		"hex json":   HexJSONEncoder,
+	}
+
+	for name, encoder := range encoders {
+		for i := range vals {
+			key, err := rlp.EncodeToBytes(uint(i))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			proofDb, err := txTries.RetrieveProof(expectedRoot, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			encodedProof, err := txTries.RetrieveProofWithEncoder(expectedRoot, key, encoder)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+
+			decoded, err := encoder.Decode(encodedProof)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+
+			for k := range proofDb.db {
+				if exists, _ := decoded.Has([]byte(k)); !exists {
+					t.Fatalf("%s: decoded proof is missing node %x", name, k)
+				}
+			}
+
+			exists, err := VerifyProof(expectedRoot, key, decoded)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if !exists {
+				t.Fatalf("%s: not able to verify decoded proof", name)
+			}
+
+			reencoded, err := encoder.Encode(expectedRoot, key, decoded)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if !bytes.Equal(encodedProof, reencoded) {
+				t.Fatalf("%s: round trip changed the encoding, expected: %x, got: %x", name, encodedProof, reencoded)
+			}
+		}
+	}
+}
+
+func TestProofEncodersAbsentKey(t *testing.T) {
+	vals := GetTransactions2()
+	expectedRoot, err := computeEthReferenceTrieHash(vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	txTries := NewTxTries()
+	err = addTrie(txTries, expectedRoot, vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := rlp.EncodeToBytes(uint(len(vals) + 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	proofDb, err := txTries.RetrieveProof(expectedRoot, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encoders := map[string]ProofEncoder{
+		"nested rlp": NestedRLPEncoder,
+		"flat rlp":   FlatRLPEncoder,
+		"hex json":   HexJSONEncoder,
+	}
+
+	for name, encoder := range encoders {
+		encodedProof, err := txTries.RetrieveProofWithEncoder(expectedRoot, key, encoder)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if encodedProof == nil {
+			t.Fatalf("%s: no proof of absence", name)
+		}
+
+		decoded, err := encoder.Decode(encodedProof)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if decoded.Len() != proofDb.Len() {
+			t.Fatalf("%s: unexpected number of nodes, expected: %d, got: %d", name, proofDb.Len(), decoded.Len())
+		}
+		for k := range proofDb.db {
+			if exists, _ := decoded.Has([]byte(k)); !exists {
+				t.Fatalf("%s: decoded proof is missing node %x", name, k)
+			}
+		}
+
+		exists, err := VerifyProof(expectedRoot, key, decoded)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if exists {
+			t.Fatalf("%s: absent key verified as present", name)
+		}
+	}
+}
+
+func TestHexJSONEncoderFormat(t *testing.T) {
+	vals := GetTransactions1()
+	expectedRoot, err := computeEthReferenceTrieHash(vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	txTries := NewTxTries()
+	err = addTrie(txTries, expectedRoot, vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := rlp.EncodeToBytes(uint(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encodedProof, err := txTries.RetrieveProofWithEncoder(expectedRoot, key, HexJSONEncoder)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var nodes []string
+	if err := json.Unmarshal(encodedProof, &nodes); err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) == 0 || nodes[0][:2] != "0x" {
+		t.Fatalf("unexpected hex json proof: %s", encodedProof)
+	}
+
+	if _, err := HexJSONEncoder.Decode([]byte(`["0xzz"]`)); err == nil {
+		t.Fatalf("expected invalid hex to fail")
+	}
+}
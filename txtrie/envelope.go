@@ -0,0 +1,249 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package txtrie
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// ProofEnvelopeVersion is the version of the envelope format produced by this package
+const ProofEnvelopeVersion uint8 = 1
+
+// TrieKind identifies the trie a proof belongs to
+type TrieKind uint8
+
+// Trie kinds supported by ProofEnvelope
+const (
+	TransactionTrie TrieKind = iota + 1
+	ReceiptTrie
+)
+
+// ProofEncoding identifies the serialization of the proof carried by an envelope
+type ProofEncoding uint8
+
+// Proof encodings supported by ProofEnvelope, each backed by one of the ProofEncoders
+const (
+	NestedRLPEncoding ProofEncoding = iota + 1
+	FlatRLPEncoding
+	HexJSONEncoding
+	ABITupleEncoding
+)
+
+// Encoder returns the ProofEncoder implementing encoding
+func (e ProofEncoding) Encoder() (ProofEncoder, error) {
+	switch e {
+	case NestedRLPEncoding:
+		return NestedRLPEncoder, nil
+	case FlatRLPEncoding:
+		return FlatRLPEncoder, nil
+	case HexJSONEncoding:
+		return HexJSONEncoder, nil
+	case ABITupleEncoding:
+		return ABITupleEncoder, nil
+	default:
+		return nil, fmt.Errorf("unknown proof encoding %d", e)
+	}
+}
+
+// ProofEnvelope is a self describing proof, carrying where the proof comes from and how it is encoded
+type ProofEnvelope struct {
+	Version     uint8
+	ChainID     *big.Int
+	TrieKind    TrieKind
+	BlockNumber uint64
+	BlockHash   common.Hash
+	Root        common.Hash
+	Key         []byte
+	Encoding    ProofEncoding
+	Proof       []byte // proof nodes serialized with Encoding
+}
+
+// jsonProofEnvelope is the JSON representation of a ProofEnvelope
+type jsonProofEnvelope struct {
+	Version     *hexutil.Uint64 `json:"version"`
+	ChainID     *hexutil.Big    `json:"chainId"`
+	TrieKind    *hexutil.Uint64 `json:"trieKind"`
+	BlockNumber *hexutil.Uint64 `json:"blockNumber"`
+	BlockHash   *common.Hash    `json:"blockHash"`
+	Root        *common.Hash    `json:"root"`
+	Key         *hexutil.Bytes  `json:"key"`
+	Encoding    *hexutil.Uint64 `json:"encoding"`
+	Proof       *hexutil.Bytes  `json:"proof"`
+}
+
+// NewProofEnvelope wraps the proof on path key in proofDb, serialized with encoding
+func NewProofEnvelope(chainID *big.Int, kind TrieKind, blockNumber uint64, blockHash common.Hash, root common.Hash, key []byte, encoding ProofEncoding, proofDb *ProofDatabase) (*ProofEnvelope, error) {
+	encoder, err := encoding.Encoder()
+	if err != nil {
+		return nil, err
+	}
+
+	proof, err := encoder.Encode(root, key, proofDb)
+	if err != nil {
+		return nil, err
+	}
+
+	envelope := &ProofEnvelope{
+		Version:     ProofEnvelopeVersion,
+		ChainID:     chainID,
+		TrieKind:    kind,
+		BlockNumber: blockNumber,
+		BlockHash:   blockHash,
+		Root:        root,
+		Key:         key,
+		Encoding:    encoding,
+		Proof:       proof,
+	}
+
+	return envelope, envelope.Validate()
+}
+
+// ProofDB decodes the proof carried by the envelope
+func (e *ProofEnvelope) ProofDB() (*ProofDatabase, error) {
+	encoder, err := e.Encoding.Encoder()
+	if err != nil {
+		return nil, err
+	}
+	return encoder.Decode(e.Proof)
+}
+
+// Validate checks that all fields of the envelope are set and that the proof proves Key against Root
+func (e *ProofEnvelope) Validate() error {
+	if e.Version != ProofEnvelopeVersion {
+		return fmt.Errorf("unsupported envelope version %d", e.Version)
+	}
+	if e.ChainID == nil || e.ChainID.Sign() <= 0 {
+		return errors.New("invalid chain id")
+	}
+	if e.TrieKind != TransactionTrie && e.TrieKind != ReceiptTrie {
+		return fmt.Errorf("unknown trie kind %d", e.TrieKind)
+	}
+	if e.BlockHash == (common.Hash{}) {
+		return errors.New("missing block hash")
+	}
+	if e.Root == (common.Hash{}) {
+		return errors.New("missing root")
+	}
+	if len(e.Key) == 0 {
+		return errors.New("missing key")
+	}
+	if len(e.Proof) == 0 {
+		return errors.New("missing proof")
+	}
+
+	if e.Encoding == ABITupleEncoding {
+		// the tuple carries its own root and key, which must be the ones of the envelope
+		proof, err := ABITupleEncoder.DecodeABIProof(e.Proof)
+		if err != nil {
+			return fmt.Errorf("invalid proof: %v", err)
+		}
+		if proof.Root != e.Root || !bytes.Equal(proof.Key, e.Key) {
+			return errors.New("proof root or key does not match the envelope")
+		}
+	}
+
+	proofDb, err := e.ProofDB()
+	if err != nil {
+		return fmt.Errorf("invalid proof: %v", err)
+	}
+	exists, err := VerifyProof(e.Root, e.Key, proofDb)
+	if err != nil {
+		return fmt.Errorf("invalid proof: %v", err)
+	}
+	if !exists {
+		return errors.New("proof does not contain key")
+	}
+
+	return nil
+}
+
+// MarshalBinary rlp encodes the envelope
+func (e *ProofEnvelope) MarshalBinary() ([]byte, error) {
+	if err := e.Validate(); err != nil {
+		return nil, err
+	}
+	return rlp.EncodeToBytes(e)
+}
+
+// UnmarshalBinary decodes an rlp encoded envelope and validates it
+func (e *ProofEnvelope) UnmarshalBinary(data []byte) error {
+	var dec ProofEnvelope
+	if err := rlp.DecodeBytes(data, &dec); err != nil {
+		return err
+	}
+	if err := dec.Validate(); err != nil {
+		return err
+	}
+	*e = dec
+	return nil
+}
+
+// MarshalJSON encodes the envelope as JSON with hex encoded fields
+func (e *ProofEnvelope) MarshalJSON() ([]byte, error) {
+	if err := e.Validate(); err != nil {
+		return nil, err
+	}
+
+	version := hexutil.Uint64(e.Version)
+	trieKind := hexutil.Uint64(e.TrieKind)
+	blockNumber := hexutil.Uint64(e.BlockNumber)
+	encoding := hexutil.Uint64(e.Encoding)
+	key := hexutil.Bytes(e.Key)
+	proof := hexutil.Bytes(e.Proof)
+
+	return json.Marshal(&jsonProofEnvelope{
+		Version:     &version,
+		ChainID:     (*hexutil.Big)(e.ChainID),
+		TrieKind:    &trieKind,
+		BlockNumber: &blockNumber,
+		BlockHash:   &e.BlockHash,
+		Root:        &e.Root,
+		Key:         &key,
+		Encoding:    &encoding,
+		Proof:       &proof,
+	})
+}
+
+// UnmarshalJSON decodes a JSON envelope, rejecting unknown and missing fields, and validates it
+func (e *ProofEnvelope) UnmarshalJSON(input []byte) error {
+	var dec jsonProofEnvelope
+	decoder := json.NewDecoder(bytes.NewReader(input))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&dec); err != nil {
+		return err
+	}
+
+	if dec.Version == nil || dec.ChainID == nil || dec.TrieKind == nil || dec.BlockNumber == nil ||
+		dec.BlockHash == nil || dec.Root == nil || dec.Key == nil || dec.Encoding == nil || dec.Proof == nil {
+		return errors.New("missing required field in proof envelope")
+	}
+	if *dec.Version > 0xff || *dec.TrieKind > 0xff || *dec.Encoding > 0xff {
+		return errors.New("proof envelope field out of range")
+	}
+
+	envelope := ProofEnvelope{
+		Version:     uint8(*dec.Version),
+		ChainID:     (*big.Int)(dec.ChainID),
+		TrieKind:    TrieKind(*dec.TrieKind),
+		BlockNumber: uint64(*dec.BlockNumber),
+		BlockHash:   *dec.BlockHash,
+		Root:        *dec.Root,
+		Key:         *dec.Key,
+		Encoding:    ProofEncoding(*dec.Encoding),
+		Proof:       *dec.Proof,
+	}
+	if err := envelope.Validate(); err != nil {
+		return err
+	}
+	*e = envelope
+	return nil
+}
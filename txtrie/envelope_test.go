@@ -0,0 +1,156 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package txtrie
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+func newTestEnvelope(t *testing.T, encoding ProofEncoding) *ProofEnvelope {
+	vals := GetTransactions1()
+	expectedRoot, err := computeEthReferenceTrieHash(vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	txTries := NewTxTries()
+	err = addTrie(txTries, expectedRoot, vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := rlp.EncodeToBytes(uint(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proofDb, err := txTries.RetrieveProof(expectedRoot, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	envelope, err := NewProofEnvelope(big.NewInt(5), TransactionTrie, 100, common.Hash{0xaa}, expectedRoot, key, encoding, proofDb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return envelope
+}
+
+func TestProofEnvelopeBinaryRoundTrip(t *testing.T) {
+	for _, encoding := range []ProofEncoding{NestedRLPEncoding, FlatRLPEncoding, HexJSONEncoding, ABITupleEncoding} {
+		envelope := newTestEnvelope(t, encoding)
+
+		data, err := envelope.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var decoded ProofEnvelope
+		if err := decoded.UnmarshalBinary(data); err != nil {
+			t.Fatalf("encoding %d: %v", encoding, err)
+		}
+
+		if decoded.Encoding != encoding || decoded.ChainID.Cmp(envelope.ChainID) != 0 || decoded.Root != envelope.Root ||
+			!bytes.Equal(decoded.Proof, envelope.Proof) {
+			t.Fatalf("encoding %d: decoded envelope does not match, expected: %+v, got: %+v", encoding, envelope, decoded)
+		}
+	}
+}
+
+func TestProofEnvelopeJSONRoundTrip(t *testing.T) {
+	envelope := newTestEnvelope(t, FlatRLPEncoding)
+
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded ProofEnvelope
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.BlockNumber != envelope.BlockNumber || decoded.BlockHash != envelope.BlockHash ||
+		decoded.TrieKind != envelope.TrieKind || !bytes.Equal(decoded.Proof, envelope.Proof) {
+		t.Fatalf("decoded envelope does not match, expected: %+v, got: %+v", envelope, decoded)
+	}
+}
+
+func TestProofEnvelopeStrictDecoding(t *testing.T) {
+	envelope := newTestEnvelope(t, NestedRLPEncoding)
+
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := map[string]string{
+		"unknown field":   strings.Replace(string(data), `"version"`, `"extra":"0x1","version"`, 1),
+		"missing field":   strings.Replace(string(data), `"trieKind":"0x1",`, "", 1),
+		"unknown version": strings.Replace(string(data), `"version":"0x1"`, `"version":"0x2"`, 1),
+		"unknown kind":    strings.Replace(string(data), `"trieKind":"0x1"`, `"trieKind":"0x9"`, 1),
+		"wrong encoding":  strings.Replace(string(data), `"encoding":"0x1"`, `"encoding":"0x2"`, 1),
+		"zero chain id":   strings.Replace(string(data), `"chainId":"0x5"`, `"chainId":"0x0"`, 1),
+	}
+	for name, input := range invalid {
+		if input == string(data) {
+			t.Fatalf("%s: test input was not modified", name)
+		}
+		var decoded ProofEnvelope
+		if err := json.Unmarshal([]byte(input), &decoded); err == nil {
+			t.Fatalf("%s: expected decoding to fail", name)
+		}
+	}
+
+	tampered := *envelope
+	tampered.Root = common.Hash{0x01}
+	if _, err := tampered.MarshalBinary(); err == nil {
+		t.Fatalf("expected envelope with wrong root to fail validation")
+	}
+
+	binary, err := envelope.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded ProofEnvelope
+	if err := decoded.UnmarshalBinary(append(binary, 0x00)); err == nil {
+		t.Fatalf("expected trailing bytes to fail")
+	}
+}
+
+func TestProofEnvelopeABITupleMismatch(t *testing.T) {
+	envelope := newTestEnvelope(t, ABITupleEncoding)
+	proof, err := ABITupleEncoder.DecodeABIProof(envelope.Proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherKey, err := rlp.EncodeToBytes(uint(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	invalid := map[string][]interface{}{
+		"different root": {[32]byte{0x01}, proof.Key, proof.Nodes},
+		"different key":  {[32]byte(proof.Root), otherKey, proof.Nodes},
+	}
+	for name, values := range invalid {
+		packed, err := ABITupleEncoder.args.Pack(values...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tampered := *envelope
+		tampered.Proof = packed
+		if err := tampered.Validate(); err == nil {
+			t.Fatalf("%s: expected tuple not matching the envelope to fail validation", name)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package txtrie
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	ethtrie "github.com/ethereum/go-ethereum/trie"
+)
+
+const (
+	// DefaultFetchRetries is the number of extra attempts made when fetching a block fails
+	DefaultFetchRetries = 3
+	// DefaultRetryDelay is the time waited between two fetch attempts
+	DefaultRetryDelay = 2 * time.Second
+)
+
+// BlockReader is the subset of go-ethereum's ethereum.ChainReader needed to fetch blocks.
+// It is satisfied by *ethclient.Client and *backends.SimulatedBackend.
+type BlockReader interface {
+	BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error)
+	BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error)
+}
+
+// Fetcher retrieves blocks from a node and populates a TxTries object with their transactions
+type Fetcher struct {
+	client     BlockReader
+	txTries    *TxTries
+	retries    int
+	retryDelay time.Duration
+}
+
+// NewFetcher creates a new Fetcher that adds the tries of fetched blocks to txTries
+func NewFetcher(client BlockReader, txTries *TxTries) *Fetcher {
+	return &Fetcher{
+		client:     client,
+		txTries:    txTries,
+		retries:    DefaultFetchRetries,
+		retryDelay: DefaultRetryDelay,
+	}
+}
+
+// SetRetries sets the number of extra attempts made after a failed fetch and the delay between them
+func (f *Fetcher) SetRetries(retries int, delay time.Duration) {
+	f.retries = retries
+	f.retryDelay = delay
+}
+
+// FetchByNumber retrieves the block with number number and adds its transaction trie
+func (f *Fetcher) FetchByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	block, err := f.retrieveByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	_, err = f.addBlock(block)
+	return block, err
+}
+
+// FetchByHash retrieves the block with hash hash and adds its transaction trie
+func (f *Fetcher) FetchByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	block, err := f.retrieveByHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	_, err = f.addBlock(block)
+	return block, err
+}
+
+// retrieveByNumber retrieves and verifies the block with number number without adding it
+func (f *Fetcher) retrieveByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	if number == nil {
+		return nil, errors.New("block number cannot be nil")
+	}
+	return f.retrieve(ctx, func() (*types.Block, error) {
+		block, err := f.client.BlockByNumber(ctx, number)
+		if err != nil {
+			return nil, err
+		}
+		if block.Number().Cmp(number) != 0 {
+			return nil, fmt.Errorf("requested block %d, got %d", number, block.Number())
+		}
+		return block, nil
+	})
+}
+
+// retrieveByHash retrieves and verifies the block with hash hash without adding it
+func (f *Fetcher) retrieveByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	return f.retrieve(ctx, func() (*types.Block, error) {
+		block, err := f.client.BlockByHash(ctx, hash)
+		if err != nil {
+			return nil, err
+		}
+		if block.Hash() != hash {
+			return nil, fmt.Errorf("requested block %x, got %x", hash, block.Hash())
+		}
+		return block, nil
+	})
+}
+
+// retrieve calls get until it returns a verified block or the retries are exhausted
+func (f *Fetcher) retrieve(ctx context.Context, get func() (*types.Block, error)) (*types.Block, error) {
+	var lastErr error
+	for attempt := 0; attempt <= f.retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(f.retryDelay):
+			}
+		}
+
+		block, err := get()
+		if err == nil {
+			err = verifyBlock(block)
+		}
+		if err == nil {
+			return block, nil
+		}
+		lastErr = err
+	}
+
+	return nil, fmt.Errorf("failed to fetch block after %d attempts: %v", f.retries+1, lastErr)
+}
+
+// verifyBlock checks that the transactions of block match the transaction root in its header
+func verifyBlock(block *types.Block) error {
+	if block == nil {
+		return errors.New("block cannot be nil")
+	}
+
+	txRoot := block.TxHash()
+	if derived := types.DeriveSha(block.Transactions(), ethtrie.NewStackTrie(nil)); derived != txRoot {
+		return fmt.Errorf("transaction root mismatch for block %d: header %x, derived %x", block.Number(), txRoot, derived)
+	}
+
+	return nil
+}
+
+// addBlock adds the transaction trie of an already verified block to the TxTries. It reports whether
+// the trie was created, which it isn't if the TxTries already held it.
+func (f *Fetcher) addBlock(block *types.Block) (bool, error) {
+	if f.txTries.hasTrie(block.TxHash()) {
+		return false, nil
+	}
+
+	return f.txTries.storeTrie(block.TxHash(), block.Transactions(), false)
+}
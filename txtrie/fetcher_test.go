@@ -0,0 +1,151 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package txtrie
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+var (
+	testKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testAddress = crypto.PubkeyToAddress(testKey.PublicKey)
+)
+
+// newTestBackend creates a simulated backend and commits a block with txCount transactions
+func newTestBackend(t *testing.T, txCount int) *backends.SimulatedBackend {
+	balance := new(big.Int).Mul(big.NewInt(1000), big.NewInt(1e18))
+	backend := backends.NewSimulatedBackend(core.GenesisAlloc{testAddress: {Balance: balance}}, 10000000)
+
+	signer := types.NewEIP155Signer(big.NewInt(1337))
+	for i := 0; i < txCount; i++ {
+		tx := types.NewTransaction(uint64(i), common.Address{0x01}, big.NewInt(1), 21000, big.NewInt(1), nil)
+		signedTx, err := types.SignTx(tx, signer, testKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := backend.SendTransaction(context.Background(), signedTx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	backend.Commit()
+
+	return backend
+}
+
+// flakyReader fails the first failures calls before delegating to reader
+type flakyReader struct {
+	BlockReader
+	failures int
+	calls    int
+}
+
+func (r *flakyReader) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	r.calls++
+	if r.calls <= r.failures {
+		return nil, errors.New("connection refused")
+	}
+	return r.BlockReader.BlockByNumber(ctx, number)
+}
+
+func TestFetchByNumber(t *testing.T) {
+	backend := newTestBackend(t, 5)
+	defer backend.Close()
+
+	txTries := NewTxTries()
+	fetcher := NewFetcher(backend, txTries)
+
+	block, err := fetcher.FetchByNumber(context.Background(), big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(block.Transactions()) != 5 {
+		t.Fatalf("unexpected transaction count, expected: %d, got: %d", 5, len(block.Transactions()))
+	}
+
+	key, err := rlp.EncodeToBytes(uint(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proofDb, err := txTries.RetrieveProof(block.TxHash(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exists, err := VerifyProof(block.TxHash(), key, proofDb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !exists {
+		t.Fatalf("not able to verify proof for fetched block")
+	}
+}
+
+func TestFetchByHash(t *testing.T) {
+	backend := newTestBackend(t, 2)
+	defer backend.Close()
+
+	head, err := backend.BlockByNumber(context.Background(), big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	txTries := NewTxTries()
+	fetcher := NewFetcher(backend, txTries)
+
+	if _, err = fetcher.FetchByHash(context.Background(), head.Hash()); err != nil {
+		t.Fatal(err)
+	}
+
+	// fetching the same block again must not store a second copy
+	if _, err = fetcher.FetchByHash(context.Background(), head.Hash()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(txTries.txRoots) != 1 {
+		t.Fatalf("unexpected number of tries, expected: %d, got: %d", 1, len(txTries.txRoots))
+	}
+}
+
+func TestFetchRetries(t *testing.T) {
+	backend := newTestBackend(t, 1)
+	defer backend.Close()
+
+	reader := &flakyReader{BlockReader: backend, failures: 2}
+	fetcher := NewFetcher(reader, NewTxTries())
+	fetcher.SetRetries(2, time.Millisecond)
+
+	if _, err := fetcher.FetchByNumber(context.Background(), big.NewInt(1)); err != nil {
+		t.Fatal(err)
+	}
+
+	if reader.calls != 3 {
+		t.Fatalf("unexpected number of calls, expected: %d, got: %d", 3, reader.calls)
+	}
+}
+
+func TestFetchRetriesExhausted(t *testing.T) {
+	backend := newTestBackend(t, 1)
+	defer backend.Close()
+
+	reader := &flakyReader{BlockReader: backend, failures: 5}
+	fetcher := NewFetcher(reader, NewTxTries())
+	fetcher.SetRetries(1, time.Millisecond)
+
+	if _, err := fetcher.FetchByNumber(context.Background(), big.NewInt(1)); err == nil {
+		t.Fatalf("expected fetch to fail")
+	}
+}
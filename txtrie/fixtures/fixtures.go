@@ -0,0 +1,261 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+// Package fixtures loads test vectors of real or synthetic blocks. Transactions, receipts and the
+// header are kept in their consensus encoding, so fixtures can hold transaction types that this
+// version of go-ethereum can't decode.
+package fixtures
+
+//go:generate go run ../../cmd/synthfixture -dir testdata
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Tries a ProofVector can refer to
+const (
+	TransactionsTrie = "transactions"
+	ReceiptsTrie     = "receipts"
+)
+
+// positions of the fields checked by Validate in the header RLP list
+const (
+	headerTxRootIndex      = 4
+	headerReceiptRootIndex = 5
+	headerNumberIndex      = 8
+)
+
+// Fixture is a block with the encoded proofs expected for some of its transactions and receipts
+type Fixture struct {
+	Name             string          `json:"name"`
+	Source           string          `json:"source"` // where the block comes from, e.g. mainnet or synthetic
+	Fork             string          `json:"fork"`   // newest transaction format in the block, e.g. legacy or london
+	BlockNumber      hexutil.Uint64  `json:"blockNumber"`
+	BlockHash        common.Hash     `json:"blockHash"`
+	Header           hexutil.Bytes   `json:"header"`             // RLP encoded header
+	Transactions     []hexutil.Bytes `json:"transactions"`       // values of the transaction trie
+	Receipts         []hexutil.Bytes `json:"receipts,omitempty"` // values of the receipt trie, nil if the source had none
+	TransactionsRoot common.Hash     `json:"transactionsRoot"`
+	ReceiptsRoot     common.Hash     `json:"receiptsRoot"`
+	Proofs           []ProofVector   `json:"proofs"`
+}
+
+// ProofVector is the proof expected from RetrieveEncodedProof for the value at Index of Trie
+type ProofVector struct {
+	Trie         string        `json:"trie"`
+	Index        uint64        `json:"index"`
+	EncodedProof hexutil.Bytes `json:"encodedProof"`
+}
+
+// Load reads and validates the fixture at path
+func Load(path string) (*Fixture, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var fixture Fixture
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&fixture); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if err := fixture.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return &fixture, nil
+}
+
+// LoadDir loads all *.json fixtures in dir, sorted by file name
+func LoadDir(dir string) ([]*Fixture, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	fixtures := make([]*Fixture, 0, len(paths))
+	for _, path := range paths {
+		fixture, err := Load(path)
+		if err != nil {
+			return nil, err
+		}
+		fixtures = append(fixtures, fixture)
+	}
+	return fixtures, nil
+}
+
+// Save writes the fixture to path as indented JSON
+func (f *Fixture) Save(path string) error {
+	if err := f.Validate(); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Validate checks that the header hashes to BlockHash and commits to the roots and number of the fixture
+func (f *Fixture) Validate() error {
+	if f.Name == "" {
+		return errors.New("missing fixture name")
+	}
+	if hash := crypto.Keccak256Hash(f.Header); hash != f.BlockHash {
+		return fmt.Errorf("header hash mismatch, expected %x, got %x", f.BlockHash, hash)
+	}
+
+	fields, err := headerFields(f.Header)
+	if err != nil {
+		return err
+	}
+	if len(fields) <= headerNumberIndex {
+		return fmt.Errorf("header has %d fields", len(fields))
+	}
+	if !bytes.Equal(fields[headerTxRootIndex], f.TransactionsRoot[:]) {
+		return fmt.Errorf("header transactions root is %x, want %x", fields[headerTxRootIndex], f.TransactionsRoot)
+	}
+	if !bytes.Equal(fields[headerReceiptRootIndex], f.ReceiptsRoot[:]) {
+		return fmt.Errorf("header receipts root is %x, want %x", fields[headerReceiptRootIndex], f.ReceiptsRoot)
+	}
+	if number := new(big.Int).SetBytes(fields[headerNumberIndex]); !number.IsUint64() || number.Uint64() != uint64(f.BlockNumber) {
+		return fmt.Errorf("header number is %x, want %d", fields[headerNumberIndex], f.BlockNumber)
+	}
+
+	for _, proof := range f.Proofs {
+		var count int
+		switch proof.Trie {
+		case TransactionsTrie:
+			count = len(f.Transactions)
+		case ReceiptsTrie:
+			count = len(f.Receipts)
+		default:
+			return fmt.Errorf("unknown trie %q", proof.Trie)
+		}
+		if proof.Index >= uint64(count) {
+			return fmt.Errorf("proof index %d out of range for %d %s", proof.Index, count, proof.Trie)
+		}
+	}
+	return nil
+}
+
+// Tries returns the tries the fixture holds the values of
+func (f *Fixture) Tries() []string {
+	if f.Receipts == nil {
+		return []string{TransactionsTrie}
+	}
+	return []string{TransactionsTrie, ReceiptsTrie}
+}
+
+// Values returns the transaction or receipt trie values as a list of byte slices
+func (f *Fixture) Values(trie string) ([][]byte, error) {
+	var encoded []hexutil.Bytes
+	switch trie {
+	case TransactionsTrie:
+		encoded = f.Transactions
+	case ReceiptsTrie:
+		encoded = f.Receipts
+	default:
+		return nil, fmt.Errorf("unknown trie %q", trie)
+	}
+
+	values := make([][]byte, len(encoded))
+	for i, value := range encoded {
+		values[i] = value
+	}
+	return values, nil
+}
+
+// Root returns the root of the transaction or receipt trie
+func (f *Fixture) Root(trie string) (common.Hash, error) {
+	switch trie {
+	case TransactionsTrie:
+		return f.TransactionsRoot, nil
+	case ReceiptsTrie:
+		return f.ReceiptsRoot, nil
+	default:
+		return common.Hash{}, fmt.Errorf("unknown trie %q", trie)
+	}
+}
+
+// HeaderRoots returns the transactions and receipts roots committed to by an RLP encoded header
+func HeaderRoots(header []byte) (txRoot common.Hash, receiptRoot common.Hash, err error) {
+	fields, err := headerFields(header)
+	if err != nil {
+		return txRoot, receiptRoot, err
+	}
+	if len(fields) <= headerReceiptRootIndex {
+		return txRoot, receiptRoot, fmt.Errorf("header has %d fields", len(fields))
+	}
+	if len(fields[headerTxRootIndex]) != len(txRoot) || len(fields[headerReceiptRootIndex]) != len(receiptRoot) {
+		return txRoot, receiptRoot, errors.New("invalid header roots")
+	}
+	return common.BytesToHash(fields[headerTxRootIndex]), common.BytesToHash(fields[headerReceiptRootIndex]), nil
+}
+
+// headerFields splits an RLP encoded header into the contents of its fields
+func headerFields(header []byte) ([][]byte, error) {
+	elems, rest, err := rlp.SplitList(header)
+	if err != nil {
+		return nil, fmt.Errorf("invalid header: %v", err)
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("invalid header: trailing bytes")
+	}
+
+	var fields [][]byte
+	for len(elems) > 0 {
+		_, content, rest, err := rlp.Split(elems)
+		if err != nil {
+			return nil, fmt.Errorf("invalid header field %d: %v", len(fields), err)
+		}
+		fields = append(fields, content)
+		elems = rest
+	}
+	return fields, nil
+}
+
+// BlockTransactions splits an RLP encoded block into the values of its transaction trie. Legacy
+// transactions are RLP lists stored as is, typed transactions are RLP strings holding the value.
+func BlockTransactions(block []byte) ([][]byte, error) {
+	elems, _, err := rlp.SplitList(block)
+	if err != nil {
+		return nil, fmt.Errorf("invalid block: %v", err)
+	}
+	// skip the header
+	if _, _, elems, err = rlp.Split(elems); err != nil {
+		return nil, fmt.Errorf("invalid block header: %v", err)
+	}
+	txs, _, err := rlp.SplitList(elems)
+	if err != nil {
+		return nil, fmt.Errorf("invalid block transactions: %v", err)
+	}
+
+	values := [][]byte{}
+	for len(txs) > 0 {
+		kind, content, rest, err := rlp.Split(txs)
+		if err != nil {
+			return nil, fmt.Errorf("invalid transaction %d: %v", len(values), err)
+		}
+		if kind == rlp.List {
+			values = append(values, common.CopyBytes(txs[:len(txs)-len(rest)]))
+		} else {
+			values = append(values, common.CopyBytes(content))
+		}
+		txs = rest
+	}
+	return values, nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package fixtures
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+func TestLoadDir(t *testing.T) {
+	fixtures, err := LoadDir("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	forks := make(map[string]bool)
+	mainnetForks := make(map[string]bool)
+	for _, fixture := range fixtures {
+		forks[fixture.Fork] = true
+		if fixture.Source == "mainnet" {
+			mainnetForks[fixture.Fork] = true
+		}
+	}
+	for _, fork := range []string{"legacy", "berlin", "london", "cancun"} {
+		if !forks[fork] {
+			t.Fatalf("no fixture for %s blocks", fork)
+		}
+	}
+	for _, fork := range []string{"london", "cancun"} {
+		if !mainnetForks[fork] {
+			t.Fatalf("no mainnet fixture for %s blocks", fork)
+		}
+	}
+}
+
+func TestFixtureWithoutReceipts(t *testing.T) {
+	fixture, err := Load(filepath.Join("testdata", "mainnet-18189758.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tries := fixture.Tries(); len(tries) != 1 || tries[0] != TransactionsTrie {
+		t.Fatalf("unexpected tries of a fixture without receipts: %v", tries)
+	}
+
+	fixture.Proofs = append(fixture.Proofs, ProofVector{Trie: ReceiptsTrie})
+	if err := fixture.Validate(); err == nil {
+		t.Fatalf("expected receipt proof of a fixture without receipts to fail")
+	}
+}
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	fixture, err := Load(filepath.Join("testdata", "synthetic-london.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "fixtures")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "fixture.json")
+	if err := fixture.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.BlockHash != fixture.BlockHash || len(loaded.Proofs) != len(fixture.Proofs) {
+		t.Fatalf("round trip changed the fixture, expected: %x, got: %x", fixture.BlockHash, loaded.BlockHash)
+	}
+
+	fixture.TransactionsRoot = common.Hash{0x01}
+	if err := fixture.Validate(); err == nil {
+		t.Fatalf("expected root not committed to by the header to fail")
+	}
+}
+
+func TestBlockTransactions(t *testing.T) {
+	legacy := rlp.RawValue{0xc3, 0x01, 0x02, 0x03}
+	typed := []byte{0x02, 0xc1, 0x05}
+	block, err := rlp.EncodeToBytes([]interface{}{rlp.RawValue{0xc0}, []interface{}{legacy, typed}, []interface{}{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := BlockTransactions(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 2 || !bytes.Equal(values[0], legacy) || !bytes.Equal(values[1], typed) {
+		t.Fatalf("unexpected transaction values: %x", values)
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package txtrie
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ChainSafe/chainbridge-ethereum-trie/txtrie/fixtures"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+const fixturesDir = "fixtures/testdata"
+
+func TestFixtures(t *testing.T) {
+	all, err := fixtures.LoadDir(fixturesDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) == 0 {
+		t.Fatalf("no fixtures found in %s", fixturesDir)
+	}
+
+	for _, fixture := range all {
+		fixture := fixture
+		t.Run(fixture.Name, func(t *testing.T) {
+			txTries := NewTxTries()
+			for _, trie := range fixture.Tries() {
+				values, err := fixture.Values(trie)
+				if err != nil {
+					t.Fatal(err)
+				}
+				root, err := fixture.Root(trie)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := txTries.CreateNewRawTrie(root, values); err != nil {
+					t.Fatalf("%s trie: %v", trie, err)
+				}
+			}
+
+			if fixture.Fork == "legacy" {
+				// legacy transactions can also be decoded and added as types.Transactions
+				txs := make(types.Transactions, len(fixture.Transactions))
+				for i, enc := range fixture.Transactions {
+					txs[i] = new(types.Transaction)
+					if err := rlp.DecodeBytes(enc, txs[i]); err != nil {
+						t.Fatal(err)
+					}
+				}
+				if err := NewTxTries().CreateNewTrie(fixture.TransactionsRoot, txs); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for _, vector := range fixture.Proofs {
+				root, err := fixture.Root(vector.Trie)
+				if err != nil {
+					t.Fatal(err)
+				}
+				values, err := fixture.Values(vector.Trie)
+				if err != nil {
+					t.Fatal(err)
+				}
+				key, err := rlp.EncodeToBytes(uint(vector.Index))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				encodedProof, err := txTries.RetrieveEncodedProof(root, key)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(encodedProof, vector.EncodedProof) {
+					t.Fatalf("%s proof %d differs, expected: %x, got: %x", vector.Trie, vector.Index, vector.EncodedProof, encodedProof)
+				}
+
+				value, err := verifyEncodedProof(root, key, vector.EncodedProof)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(value, values[vector.Index]) {
+					t.Fatalf("%s proof %d proves the wrong value, expected: %x, got: %x", vector.Trie, vector.Index, values[vector.Index], value)
+				}
+
+				proofDb, err := txTries.RetrieveProof(root, key)
+				if err != nil {
+					t.Fatal(err)
+				}
+				exists, err := VerifyProofStrict(root, key, proofDb)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !exists {
+					t.Fatalf("not able to verify %s proof %d", vector.Trie, vector.Index)
+				}
+			}
+		})
+	}
+}
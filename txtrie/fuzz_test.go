@@ -0,0 +1,145 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+//go:build go1.18
+// +build go1.18
+
+package txtrie
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ChainSafe/chainbridge-ethereum-trie/txtrie/trienode"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// fuzzSeed is a valid proof from the test data
+type fuzzSeed struct {
+	root         common.Hash
+	key          []byte
+	nodes        [][]byte // proof nodes starting at the root
+	encodedProof []byte
+}
+
+// fuzzSeeds returns the proofs of every transaction and of a missing key in the test data
+func fuzzSeeds(f *testing.F) []fuzzSeed {
+	var seeds []fuzzSeed
+	for _, vals := range []types.Transactions{GetTransactions1(), GetTransactions2(), GetTransactions3()} {
+		root, err := computeEthReferenceTrieHash(vals)
+		if err != nil {
+			f.Fatal(err)
+		}
+		txTries := NewTxTries()
+		if err := txTries.CreateNewTrie(root, vals); err != nil {
+			f.Fatal(err)
+		}
+
+		for i := 0; i <= len(vals); i++ {
+			key, err := rlp.EncodeToBytes(uint(i))
+			if err != nil {
+				f.Fatal(err)
+			}
+			proofDb, err := txTries.RetrieveProof(root, key)
+			if err != nil {
+				f.Fatal(err)
+			}
+			nodes, err := proofPathNodes(root, key, proofDb)
+			if err != nil {
+				f.Fatal(err)
+			}
+			encodedProof, err := encodeProofDB(root, key, proofDb)
+			if err != nil {
+				f.Fatal(err)
+			}
+			seeds = append(seeds, fuzzSeed{root: root, key: key, nodes: nodes, encodedProof: encodedProof})
+		}
+	}
+	return seeds
+}
+
+func FuzzDecodeNode(f *testing.F) {
+	for _, seed := range fuzzSeeds(f) {
+		for _, n := range seed.nodes {
+			f.Add(n, seed.key)
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, buf []byte, key []byte) {
+		n, err := decodeNode(crypto.Keccak256(buf), buf)
+		if err != nil {
+			return
+		}
+		n.fstring("")
+		if _, _, err := get(n, trienode.KeybytesToHex(key), false); err != nil {
+			return
+		}
+		if _, _, err := get(n, trienode.KeybytesToHex(key), true); err != nil {
+			t.Fatalf("resolving node failed after a single step succeeded: %v", err)
+		}
+	})
+}
+
+func FuzzVerifyProof(f *testing.F) {
+	for _, seed := range fuzzSeeds(f) {
+		enc, err := rlp.EncodeToBytes(seed.nodes)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(enc, seed.key)
+	}
+
+	f.Fuzz(func(t *testing.T, encodedNodes []byte, key []byte) {
+		var nodes [][]byte
+		if err := rlp.DecodeBytes(encodedNodes, &nodes); err != nil || len(nodes) == 0 {
+			return
+		}
+		proofDb, err := nodesToProofDB(nodes)
+		if err != nil {
+			return
+		}
+		root := crypto.Keccak256Hash(nodes[0])
+
+		value, err := verifyProof(root, key, proofDb)
+		if err != nil {
+			return
+		}
+		if _, err := encodeProofDB(root, key, proofDb); err != nil {
+			t.Fatalf("encoding a verified proof failed: %v", err)
+		}
+		if _, err := TraceProof(root, key, proofDb); err != nil {
+			t.Fatalf("tracing a verified proof failed: %v", err)
+		}
+		if _, _, err := PruneProof(proofDb, root, key); err != nil {
+			t.Fatalf("pruning a verified proof failed: %v", err)
+		}
+		strictValue, err := verifyProofStrict(root, key, proofDb)
+		if err == nil && !bytes.Equal(value, strictValue) {
+			t.Fatalf("strict verification returned a different value, expected: %x, got: %x", value, strictValue)
+		}
+	})
+}
+
+func FuzzVerifyEncodedProof(f *testing.F) {
+	for _, seed := range fuzzSeeds(f) {
+		f.Add(seed.root[:], seed.key, seed.encodedProof)
+	}
+
+	f.Fuzz(func(t *testing.T, root []byte, key []byte, encodedProof []byte) {
+		value, err := verifyEncodedProof(common.BytesToHash(root), key, encodedProof)
+		if err != nil || value == nil {
+			return
+		}
+		proofDb, err := NestedRLPEncoder.Decode(encodedProof)
+		if err != nil {
+			t.Fatalf("decoding a verified proof failed: %v", err)
+		}
+		expected, err := verifyProof(common.BytesToHash(root), key, proofDb)
+		if err != nil || !bytes.Equal(value, expected) {
+			t.Fatalf("decoded proof verifies differently, expected: %x, got: %x (%v)", value, expected, err)
+		}
+	})
+}
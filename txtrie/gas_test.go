@@ -0,0 +1,167 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package txtrie
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/ChainSafe/chainbridge-ethereum-trie/bindings/txproofverifier"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+func TestEstimateProofCost(t *testing.T) {
+	vals := GetTransactions1()
+	expectedRoot, err := computeEthReferenceTrieHash(vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	txTries := NewTxTries()
+	err = addTrie(txTries, expectedRoot, vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := rlp.EncodeToBytes(uint(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encodedProof, err := txTries.RetrieveEncodedProof(expectedRoot, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cost, err := EstimateEncodedProofCost(expectedRoot, key, encodedProof, DefaultGasCostModel)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// selector, the root and two offsets, then the length and padded contents of the key and the proof
+	expectedCalldataBytes := 4 + 3*32 + 32 + padded(len(key)) + 32 + padded(len(encodedProof))
+	if cost.ProofBytes != len(encodedProof) || cost.CalldataBytes != expectedCalldataBytes {
+		t.Fatalf("unexpected sizes, expected: %d proof and %d call data bytes, got: %+v", len(encodedProof), expectedCalldataBytes, cost)
+	}
+	if cost.ZeroBytes+cost.NonZeroBytes != cost.CalldataBytes {
+		t.Fatalf("unexpected byte counts: %+v", cost)
+	}
+	if cost.NodeCount != 3 {
+		t.Fatalf("unexpected node count, expected: %d, got: %d", 3, cost.NodeCount)
+	}
+	expectedCalldataGas := uint64(cost.ZeroBytes)*4 + uint64(cost.NonZeroBytes)*16
+	if cost.CalldataGas != expectedCalldataGas {
+		t.Fatalf("unexpected calldata gas, expected: %d, got: %d", expectedCalldataGas, cost.CalldataGas)
+	}
+	if cost.TotalGas != cost.CalldataGas+cost.VerificationGas || cost.VerificationGas <= DefaultGasCostModel.BaseGas {
+		t.Fatalf("unexpected gas totals: %+v", cost)
+	}
+
+	proofDb, err := txTries.RetrieveProof(expectedRoot, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dbCost, err := EstimateProofCost(expectedRoot, key, proofDb, DefaultGasCostModel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *dbCost != *cost {
+		t.Fatalf("estimates differ, expected: %+v, got: %+v", cost, dbCost)
+	}
+}
+
+func TestEstimateProofCostModel(t *testing.T) {
+	vals := GetTransactions1()
+	expectedRoot, err := computeEthReferenceTrieHash(vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	txTries := NewTxTries()
+	err = addTrie(txTries, expectedRoot, vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := rlp.EncodeToBytes(uint(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	encodedProof, err := txTries.RetrieveEncodedProof(expectedRoot, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cost, err := EstimateEncodedProofCost(expectedRoot, key, encodedProof, GasCostModel{NonZeroByteGas: 1, FullNodeGas: 100, ShortNodeGas: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cost.CalldataGas != uint64(cost.NonZeroBytes) || cost.VerificationGas != uint64(cost.FullNodeCount)*100+uint64(cost.ShortNodeCount)*10 {
+		t.Fatalf("custom cost model not applied: %+v", cost)
+	}
+
+	if _, err := EstimateEncodedProofCost(expectedRoot, key, []byte{0x01}, DefaultGasCostModel); err == nil {
+		t.Fatalf("expected invalid proof to fail")
+	}
+}
+
+// padded rounds n up to a multiple of 32
+func padded(n int) int {
+	return (n + 31) / 32 * 32
+}
+
+func TestEstimateProofCostMatchesVerifier(t *testing.T) {
+	backend, address, _ := deployTestVerifier(t)
+	defer backend.Close()
+
+	verifierABI, err := abi.JSON(strings.NewReader(txproofverifier.TxProofVerifierABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the default model is expected within 10% of the gas estimated by the node
+	rng := rand.New(rand.NewSource(1))
+	for _, count := range []int{1, 16, 100, 1000} {
+		for _, maxValueSize := range []int{8, 200} {
+			txTries, root := newRandomTxTries(t, rng, count, maxValueSize)
+
+			// the last key is absent
+			for _, i := range []int{0, count / 2, count - 1, count} {
+				key, err := rlp.EncodeToBytes(uint(i))
+				if err != nil {
+					t.Fatal(err)
+				}
+				proofDb, err := txTries.RetrieveProof(root, key)
+				if err != nil {
+					t.Fatal(err)
+				}
+				encodedProof, err := encodeProofDB(root, key, proofDb)
+				if err != nil {
+					t.Fatal(err)
+				}
+				cost, err := EstimateEncodedProofCost(root, key, encodedProof, DefaultGasCostModel)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				callData, err := verifierABI.Pack("verifyProof", root, key, encodedProof)
+				if err != nil {
+					t.Fatal(err)
+				}
+				gas, err := backend.EstimateGas(context.Background(), ethereum.CallMsg{From: testAddress, To: &address, Data: callData})
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if cost.TotalGas < gas-gas/10 || cost.TotalGas > gas+gas/10 {
+					t.Fatalf("estimate of key %x in trie of %d values out of range, expected: %d, got: %d (%+v)", key, count, gas, cost.TotalGas, cost)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package txtrie
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ChainSafe/chainbridge-ethereum-trie/txtrie/txgen"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+func TestGeneratedBlocksProveEveryKey(t *testing.T) {
+	g, err := txgen.NewGenerator(txgen.Config{Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, n := range []int{0, 1, 6, 50, 300} {
+		block, receipts, err := g.Block(n)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		txs := block.Transactions()
+		if txs == nil {
+			// blocks without transactions return a nil list
+			txs = types.Transactions{}
+		}
+
+		txTries := NewTxTries()
+		if err := txTries.CreateNewTrie(block.TxHash(), txs); err != nil {
+			t.Fatal(err)
+		}
+		if err := txTries.CreateNewReceiptTrie(block.ReceiptHash(), receipts); err != nil {
+			t.Fatal(err)
+		}
+
+		for i, tx := range txs {
+			key, err := rlp.EncodeToBytes(uint(i))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			proofDb, err := txTries.RetrieveProof(block.TxHash(), key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			value, err := verifyProofStrict(block.TxHash(), key, proofDb)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected, err := rlp.EncodeToBytes(tx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(value, expected) {
+				t.Fatalf("unexpected transaction %d in block %d, expected: %x, got: %x", i, block.NumberU64(), expected, value)
+			}
+
+			receiptProof, err := txTries.RetrieveProof(block.ReceiptHash(), key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			exists, err := VerifyProofStrict(block.ReceiptHash(), key, receiptProof)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !exists {
+				t.Fatalf("not able to verify receipt %d in block %d", i, block.NumberU64())
+			}
+		}
+	}
+}
@@ -0,0 +1,273 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package txtrie
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	// DefaultPollInterval is the time waited between two checks for a new head
+	DefaultPollInterval = 5 * time.Second
+	// DefaultRetainedBlocks is the number of most recent blocks whose tries are kept
+	DefaultRetainedBlocks = 128
+)
+
+// ChainReader is the subset of go-ethereum's ethereum.ChainReader the Listener relies on
+type ChainReader interface {
+	BlockReader
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// HeadSubscriber is implemented by chain readers that can push new heads, such as *ethclient.Client
+// connected over a websocket. Listeners created with one use it in addition to polling.
+type HeadSubscriber interface {
+	SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error)
+}
+
+// ListenerConfig holds the options of a Listener
+type ListenerConfig struct {
+	StartBlock     *big.Int      // first block to ingest, defaults to the latest confirmed block
+	Confirmations  uint64        // number of blocks a block must be buried under before it is ingested
+	RetainedBlocks int           // number of most recent blocks whose tries are kept, older ones are pruned
+	PollInterval   time.Duration // time between two checks for a new head
+	ErrorHandler   func(error)   // called with errors encountered while following the chain, may be nil
+}
+
+// blockRef identifies an ingested block
+type blockRef struct {
+	number uint64
+	hash   common.Hash
+	txRoot common.Hash
+}
+
+// Listener follows the head of a chain and keeps a TxTries object populated with the tries
+// of the confirmed blocks, removing the tries of blocks that are reorganised out or pruned
+type Listener struct {
+	client  ChainReader
+	fetcher *Fetcher
+	txTries *TxTries
+	cfg     ListenerConfig
+
+	blocks []blockRef          // ingested canonical blocks, oldest first
+	refs   map[common.Hash]int // number of ingested blocks referencing each transaction root the listener created
+	next   *big.Int            // number of the next block to ingest
+	lock   sync.Mutex          // protects blocks, refs and next
+
+	stop    chan struct{}
+	done    chan struct{}
+	runLock sync.Mutex // protects stop and done
+}
+
+// NewListener creates a new Listener that ingests the blocks of client into txTries
+func NewListener(client ChainReader, txTries *TxTries, cfg ListenerConfig) *Listener {
+	if cfg.RetainedBlocks <= 0 {
+		cfg.RetainedBlocks = DefaultRetainedBlocks
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = DefaultPollInterval
+	}
+
+	var next *big.Int
+	if cfg.StartBlock != nil {
+		next = new(big.Int).Set(cfg.StartBlock)
+	}
+
+	return &Listener{
+		client:  client,
+		fetcher: NewFetcher(client, txTries),
+		txTries: txTries,
+		cfg:     cfg,
+		refs:    make(map[common.Hash]int),
+		next:    next,
+	}
+}
+
+// Fetcher returns the Fetcher used by the Listener so its retry behaviour can be adjusted
+func (l *Listener) Fetcher() *Fetcher {
+	return l.fetcher
+}
+
+// Start launches the goroutine following the chain
+func (l *Listener) Start() error {
+	l.runLock.Lock()
+	defer l.runLock.Unlock()
+
+	if l.stop != nil {
+		return errors.New("listener already started")
+	}
+	l.stop = make(chan struct{})
+	l.done = make(chan struct{})
+
+	go l.run(l.stop, l.done)
+
+	return nil
+}
+
+// Stop terminates the goroutine following the chain and waits for it to exit
+func (l *Listener) Stop() {
+	l.runLock.Lock()
+	defer l.runLock.Unlock()
+
+	if l.stop == nil {
+		return
+	}
+	close(l.stop)
+	<-l.done
+	l.stop = nil
+}
+
+// run syncs on every new head or poll tick until stop is closed, then closes done
+func (l *Listener) run(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	heads := make(chan *types.Header, 1)
+	if subscriber, ok := l.client.(HeadSubscriber); ok {
+		sub, err := subscriber.SubscribeNewHead(ctx, heads)
+		if err != nil {
+			l.handleError(err)
+		} else {
+			defer sub.Unsubscribe()
+		}
+	}
+
+	ticker := time.NewTicker(l.cfg.PollInterval)
+	defer ticker.Stop()
+
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	for {
+		if err := l.Sync(ctx); err != nil && ctx.Err() == nil {
+			l.handleError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-heads:
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sync ingests all confirmed blocks up to the current head, resolving any reorganisation
+// encountered on the way. It is called by the running listener but may also be used to
+// drive a Listener manually.
+func (l *Listener) Sync(ctx context.Context) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	head, err := l.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if head.Number.Uint64() < l.cfg.Confirmations {
+		return nil
+	}
+	target := new(big.Int).SetUint64(head.Number.Uint64() - l.cfg.Confirmations)
+
+	if l.next == nil {
+		l.next = new(big.Int).Set(target)
+	}
+
+	for l.next.Cmp(target) <= 0 {
+		block, err := l.fetcher.retrieveByNumber(ctx, l.next)
+		if err != nil {
+			return err
+		}
+
+		if len(l.blocks) > 0 && block.ParentHash() != l.blocks[len(l.blocks)-1].hash {
+			// the block we built on is no longer canonical, step back and re-ingest it
+			l.rewind()
+			continue
+		}
+
+		if err := l.ingest(block); err != nil {
+			return err
+		}
+		l.next.Add(l.next, common.Big1)
+	}
+
+	return nil
+}
+
+// Head returns the number and hash of the most recently ingested block
+func (l *Listener) Head() (uint64, common.Hash, bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if len(l.blocks) == 0 {
+		return 0, common.Hash{}, false
+	}
+	last := l.blocks[len(l.blocks)-1]
+	return last.number, last.hash, true
+}
+
+// ingest adds block to the TxTries and prunes the blocks that fell out of the retained window. Tries
+// the TxTries already held are left to whoever added them.
+func (l *Listener) ingest(block *types.Block) error {
+	created, err := l.fetcher.addBlock(block)
+	if err != nil {
+		return err
+	}
+
+	l.blocks = append(l.blocks, blockRef{
+		number: block.NumberU64(),
+		hash:   block.Hash(),
+		txRoot: block.TxHash(),
+	})
+	if created || l.refs[block.TxHash()] > 0 {
+		l.refs[block.TxHash()]++
+	}
+
+	for len(l.blocks) > l.cfg.RetainedBlocks {
+		l.release(l.blocks[0])
+		l.blocks = l.blocks[1:]
+	}
+
+	return nil
+}
+
+// rewind drops the most recently ingested block so that it is fetched again
+func (l *Listener) rewind() {
+	last := l.blocks[len(l.blocks)-1]
+	l.blocks = l.blocks[:len(l.blocks)-1]
+	l.release(last)
+	l.next.SetUint64(last.number)
+}
+
+// release removes the trie of ref once no other ingested block shares its transaction root, tries the
+// listener didn't create are kept
+func (l *Listener) release(ref blockRef) {
+	if l.refs[ref.txRoot] == 0 {
+		return
+	}
+	l.refs[ref.txRoot]--
+	if l.refs[ref.txRoot] > 0 {
+		return
+	}
+	delete(l.refs, ref.txRoot)
+	l.txTries.DeleteTrie(ref.txRoot)
+}
+
+func (l *Listener) handleError(err error) {
+	if l.cfg.ErrorHandler != nil {
+		l.cfg.ErrorHandler(err)
+	}
+}
@@ -0,0 +1,257 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package txtrie
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	ethtrie "github.com/ethereum/go-ethereum/trie"
+)
+
+// testChain is a synthetic chain whose canonical blocks can be replaced to simulate reorgs
+type testChain struct {
+	lock      sync.Mutex
+	canonical []*types.Block
+	byHash    map[common.Hash]*types.Block
+}
+
+func newTestChain() *testChain {
+	genesis := types.NewBlock(&types.Header{Number: big.NewInt(0)}, nil, nil, nil, ethtrie.NewStackTrie(nil))
+	return &testChain{
+		canonical: []*types.Block{genesis},
+		byHash:    map[common.Hash]*types.Block{genesis.Hash(): genesis},
+	}
+}
+
+// extend appends count blocks to the chain after dropping all blocks above from,
+// fork is used to make the transactions differ from previously generated blocks
+func (c *testChain) extend(from uint64, count int, fork int64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.canonical = c.canonical[:from+1]
+	for i := 0; i < count; i++ {
+		parent := c.canonical[len(c.canonical)-1]
+		number := new(big.Int).Add(parent.Number(), common.Big1)
+		header := &types.Header{ParentHash: parent.Hash(), Number: number, Extra: big.NewInt(fork).Bytes()}
+		txs := types.Transactions{
+			types.NewTransaction(number.Uint64(), common.Address{0x01}, big.NewInt(fork), 21000, big.NewInt(1), nil),
+			types.NewTransaction(number.Uint64()+1, common.Address{0x02}, big.NewInt(fork), 21000, big.NewInt(1), nil),
+		}
+		block := types.NewBlock(header, txs, nil, nil, ethtrie.NewStackTrie(nil))
+		c.canonical = append(c.canonical, block)
+		c.byHash[block.Hash()] = block
+	}
+}
+
+func (c *testChain) block(number uint64) *types.Block {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.canonical[number]
+}
+
+func (c *testChain) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if block, ok := c.byHash[hash]; ok {
+		return block, nil
+	}
+	return nil, errors.New("not found")
+}
+
+func (c *testChain) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if number == nil {
+		return c.canonical[len(c.canonical)-1], nil
+	}
+	if number.Uint64() >= uint64(len(c.canonical)) {
+		return nil, errors.New("not found")
+	}
+	return c.canonical[number.Uint64()], nil
+}
+
+func (c *testChain) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	block, err := c.BlockByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	return block.Header(), nil
+}
+
+func newTestListener(chain *testChain, txTries *TxTries, cfg ListenerConfig) *Listener {
+	listener := NewListener(chain, txTries, cfg)
+	listener.Fetcher().SetRetries(0, 0)
+	return listener
+}
+
+func TestListenerConfirmations(t *testing.T) {
+	chain := newTestChain()
+	chain.extend(0, 10, 0)
+
+	txTries := NewTxTries()
+	listener := newTestListener(chain, txTries, ListenerConfig{StartBlock: big.NewInt(1), Confirmations: 2})
+
+	if err := listener.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	number, hash, ok := listener.Head()
+	if !ok || number != 8 || hash != chain.block(8).Hash() {
+		t.Fatalf("unexpected head, expected: %d, got: %d", 8, number)
+	}
+
+	for i := uint64(1); i <= 10; i++ {
+		if stored := txTries.hasTrie(chain.block(i).TxHash()); stored != (i <= 8) {
+			t.Fatalf("unexpected trie state for block %d, stored: %v", i, stored)
+		}
+	}
+}
+
+func TestListenerReorg(t *testing.T) {
+	chain := newTestChain()
+	chain.extend(0, 6, 0)
+
+	txTries := NewTxTries()
+	listener := newTestListener(chain, txTries, ListenerConfig{StartBlock: big.NewInt(1)})
+
+	if err := listener.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var orphaned []common.Hash
+	for i := uint64(4); i <= 6; i++ {
+		orphaned = append(orphaned, chain.block(i).TxHash())
+	}
+
+	// replace blocks 4-6 with a longer fork
+	chain.extend(3, 4, 1)
+
+	if err := listener.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	number, hash, _ := listener.Head()
+	if number != 7 || hash != chain.block(7).Hash() {
+		t.Fatalf("unexpected head after reorg, expected: %x, got: %x", chain.block(7).Hash(), hash)
+	}
+
+	for _, root := range orphaned {
+		if txTries.hasTrie(root) {
+			t.Fatalf("trie of orphaned block was not removed: %x", root)
+		}
+	}
+	for i := uint64(1); i <= 7; i++ {
+		if !txTries.hasTrie(chain.block(i).TxHash()) {
+			t.Fatalf("trie of canonical block %d missing", i)
+		}
+	}
+}
+
+func TestListenerPrunes(t *testing.T) {
+	chain := newTestChain()
+	chain.extend(0, 10, 0)
+
+	txTries := NewTxTries()
+	listener := newTestListener(chain, txTries, ListenerConfig{StartBlock: big.NewInt(1), RetainedBlocks: 3})
+
+	if err := listener.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(txTries.txRoots) != 3 {
+		t.Fatalf("unexpected number of tries, expected: %d, got: %d", 3, len(txTries.txRoots))
+	}
+	for i := uint64(8); i <= 10; i++ {
+		if !txTries.hasTrie(chain.block(i).TxHash()) {
+			t.Fatalf("trie of block %d missing", i)
+		}
+	}
+}
+
+func TestListenerStartStop(t *testing.T) {
+	chain := newTestChain()
+	chain.extend(0, 3, 0)
+
+	txTries := NewTxTries()
+	listener := newTestListener(chain, txTries, ListenerConfig{StartBlock: big.NewInt(1), PollInterval: time.Millisecond})
+
+	if err := listener.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := listener.Start(); err == nil {
+		t.Fatalf("expected second start to fail")
+	}
+
+	chain.extend(3, 2, 0)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if number, _, _ := listener.Head(); number == 5 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("listener did not reach head in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	listener.Stop()
+}
+
+func TestListenerKeepsExistingTries(t *testing.T) {
+	chain := newTestChain()
+	chain.extend(0, 6, 0)
+
+	// block 2 was added by someone else before the listener started
+	txTries := NewTxTries()
+	existing := chain.block(2)
+	if err := txTries.CreateNewTrie(existing.TxHash(), existing.Transactions()); err != nil {
+		t.Fatal(err)
+	}
+
+	listener := newTestListener(chain, txTries, ListenerConfig{StartBlock: big.NewInt(1), RetainedBlocks: 2})
+	if err := listener.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if !txTries.hasTrie(existing.TxHash()) {
+		t.Fatalf("listener removed a trie it did not create")
+	}
+	for i := uint64(1); i <= 6; i++ {
+		if i == 2 {
+			continue
+		}
+		if stored := txTries.hasTrie(chain.block(i).TxHash()); stored != (i >= 5) {
+			t.Fatalf("unexpected trie state for block %d, stored: %v", i, stored)
+		}
+	}
+}
+
+func TestListenerConcurrentStartStop(t *testing.T) {
+	chain := newTestChain()
+	chain.extend(0, 3, 0)
+
+	listener := newTestListener(chain, NewTxTries(), ListenerConfig{StartBlock: big.NewInt(1), PollInterval: time.Millisecond})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				_ = listener.Start()
+				listener.Stop()
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,150 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package txtrie
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// LogProof proves a single log of a receipt included in a receipt trie
+type LogProof struct {
+	ReceiptRoot  common.Hash
+	Key          []byte // rlp encoded transaction index, the path in the receipt trie
+	ReceiptProof []byte // encoded proof of the receipt in the receipt trie
+	LogIndex     uint   // index of the log within the receipt, not within the block
+	Address      common.Address
+	Topics       []common.Hash
+	Data         []byte
+}
+
+// encodedLogProof is the layout submitted to the on chain verifier. After verifying ReceiptProof
+// against the receipt root, the verifier reads the receipt's log list at LogIndex and compares it
+// with Log, which holds the consensus encoding [address, topics, data].
+type encodedLogProof struct {
+	ReceiptRoot  common.Hash
+	Key          []byte
+	ReceiptProof []byte
+	LogIndex     uint
+	Log          *types.Log
+}
+
+// Log returns the proven log, only the consensus fields are set
+func (p *LogProof) Log() *types.Log {
+	return &types.Log{
+		Address: p.Address,
+		Topics:  p.Topics,
+		Data:    p.Data,
+	}
+}
+
+// Encode rlp encodes a LogProof to a format parsable by the on chain verifier
+func (p *LogProof) Encode() ([]byte, error) {
+	return rlp.EncodeToBytes(&encodedLogProof{
+		ReceiptRoot:  p.ReceiptRoot,
+		Key:          p.Key,
+		ReceiptProof: p.ReceiptProof,
+		LogIndex:     p.LogIndex,
+		Log:          p.Log(),
+	})
+}
+
+// RetrieveLogProof retrieves a proof for the log at logIndex in the receipt at key in the receipt trie with root root
+func (t *TxTries) RetrieveLogProof(root common.Hash, key []byte, logIndex uint) (*LogProof, error) {
+	proofDB, err := t.RetrieveProof(root, key)
+	if err != nil {
+		return nil, err
+	}
+
+	log, err := VerifyLogProof(root, key, logIndex, proofDB)
+	if err != nil {
+		return nil, err
+	}
+
+	receiptProof, err := encodeProofDB(root, key, proofDB)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LogProof{
+		ReceiptRoot:  root,
+		Key:          key,
+		ReceiptProof: receiptProof,
+		LogIndex:     logIndex,
+		Address:      log.Address,
+		Topics:       log.Topics,
+		Data:         log.Data,
+	}, nil
+}
+
+// VerifyLogProof verifies the receipt proof on path key against the provided root and returns
+// the log at logIndex within the proven receipt
+func VerifyLogProof(root common.Hash, key []byte, logIndex uint, proof *ProofDatabase) (*types.Log, error) {
+	value, err := verifyProof(root, key, proof)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, errors.New("receipt not found in trie")
+	}
+
+	receipt, err := decodeConsensusReceipt(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid receipt: %v", err)
+	}
+
+	if logIndex >= uint(len(receipt.Logs)) {
+		return nil, fmt.Errorf("log index %d out of range, receipt has %d logs", logIndex, len(receipt.Logs))
+	}
+
+	return receipt.Logs[logIndex], nil
+}
+
+// consensusReceipt is the consensus encoding of a receipt, the payload of typed receipts
+type consensusReceipt struct {
+	PostStateOrStatus []byte
+	CumulativeGasUsed uint64
+	Bloom             types.Bloom
+	Logs              []*types.Log
+}
+
+// decodeConsensusReceipt decodes a value of a receipt trie. Typed receipts are prefixed with their
+// EIP-2718 type, which this version of go-ethereum can't decode, so the payload is decoded directly.
+func decodeConsensusReceipt(value []byte) (*consensusReceipt, error) {
+	if len(value) > 0 && value[0] <= 0x7f {
+		value = value[1:]
+	}
+
+	var receipt consensusReceipt
+	if err := rlp.DecodeBytes(value, &receipt); err != nil {
+		return nil, err
+	}
+	return &receipt, nil
+}
+
+// ProveLog produces a LogProof for log, locating it within the receipt of the transaction that emitted it
+func (p *DepositProver) ProveLog(ctx context.Context, log types.Log) (*LogProof, error) {
+	depositProof, err := p.ProveDeposit(ctx, log)
+	if err != nil {
+		return nil, err
+	}
+
+	receipt, err := p.client.TransactionReceipt(ctx, log.TxHash)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, receiptLog := range receipt.Logs {
+		if receiptLog.Index == log.Index {
+			return p.txTries.RetrieveLogProof(depositProof.ReceiptRoot, depositProof.Key, uint(i))
+		}
+	}
+
+	return nil, fmt.Errorf("log %d not found in receipt of transaction %x", log.Index, log.TxHash)
+}
@@ -0,0 +1,172 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package txtrie
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ChainSafe/chainbridge-ethereum-trie/txtrie/fixtures"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// multiLogEmitterCode is init code that emits LOG1(0xff) with data 0xab followed by LOG2(0x01, 0x02)
+var multiLogEmitterCode = common.FromHex("0x60ab600053" + "60ff60016000a1" + "6002600160006000a2" + "00")
+
+// emitTestLogs commits a block with count contract creations that each emit two logs
+func emitTestLogs(t *testing.T, backend *backends.SimulatedBackend, count int) []*types.Receipt {
+	var receipts []*types.Receipt
+	var hashes []common.Hash
+	for i := 0; i < count; i++ {
+		tx := types.NewContractCreation(uint64(i), big.NewInt(0), 100000, big.NewInt(1), multiLogEmitterCode)
+		signedTx, err := types.SignTx(tx, types.NewEIP155Signer(big.NewInt(1337)), testKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := backend.SendTransaction(context.Background(), signedTx); err != nil {
+			t.Fatal(err)
+		}
+		hashes = append(hashes, signedTx.Hash())
+	}
+	backend.Commit()
+
+	for _, hash := range hashes {
+		receipt, err := backend.TransactionReceipt(context.Background(), hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		receipts = append(receipts, receipt)
+	}
+	return receipts
+}
+
+func TestProveLog(t *testing.T) {
+	backend := newTestBackend(t, 0)
+	defer backend.Close()
+
+	receipts := emitTestLogs(t, backend, 2)
+	log := *receipts[1].Logs[1]
+
+	prover := NewDepositProver(backend, NewTxTries())
+	logProof, err := prover.ProveLog(context.Background(), log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if logProof.LogIndex != 1 {
+		t.Fatalf("unexpected log index, expected: %d, got: %d", 1, logProof.LogIndex)
+	}
+	if logProof.Address != log.Address || len(logProof.Topics) != 2 || logProof.Topics[1] != common.BigToHash(big.NewInt(2)) {
+		t.Fatalf("proven log does not match, expected: %v, got: %v", log, logProof.Log())
+	}
+
+	encoded, err := logProof.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded encodedLogProof
+	if err := rlp.DecodeBytes(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.LogIndex != 1 || decoded.Log.Topics[0] != log.Topics[0] || !bytes.Equal(decoded.ReceiptProof, logProof.ReceiptProof) {
+		t.Fatalf("decoded log proof does not match")
+	}
+}
+
+func TestRetrieveLogProof(t *testing.T) {
+	backend := newTestBackend(t, 0)
+	defer backend.Close()
+
+	receipts := emitTestLogs(t, backend, 1)
+
+	block, err := backend.BlockByHash(context.Background(), receipts[0].BlockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	txTries := NewTxTries()
+	if err := txTries.CreateNewReceiptTrie(block.ReceiptHash(), receipts); err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := rlp.EncodeToBytes(uint(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logProof, err := txTries.RetrieveLogProof(block.ReceiptHash(), key, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(logProof.Data, []byte{0xab}) || logProof.Topics[0] != common.BigToHash(big.NewInt(0xff)) {
+		t.Fatalf("proven log does not match, got: %v", logProof.Log())
+	}
+
+	if _, err := txTries.RetrieveLogProof(block.ReceiptHash(), key, 2); err == nil {
+		t.Fatalf("expected out of range log index to fail")
+	}
+}
+
+func TestVerifyLogProofTypedReceipts(t *testing.T) {
+	fixture, err := fixtures.Load(fixturesDir + "/synthetic-london.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, err := fixture.Values(fixtures.ReceiptsTrie)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	txTries := NewTxTries()
+	if err := txTries.CreateNewRawTrie(fixture.ReceiptsRoot, values); err != nil {
+		t.Fatal(err)
+	}
+
+	provenTyped := 0
+	for i, value := range values {
+		payload := value
+		if value[0] <= 0x7f {
+			payload = value[1:]
+		}
+		var receipt struct {
+			PostStateOrStatus []byte
+			CumulativeGasUsed uint64
+			Bloom             types.Bloom
+			Logs              []rlp.RawValue
+		}
+		if err := rlp.DecodeBytes(payload, &receipt); err != nil {
+			t.Fatal(err)
+		}
+		if len(receipt.Logs) == 0 {
+			continue
+		}
+
+		key, err := rlp.EncodeToBytes(uint(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		logProof, err := txTries.RetrieveLogProof(fixture.ReceiptsRoot, key, uint(len(receipt.Logs)-1))
+		if err != nil {
+			t.Fatalf("receipt %d of type %x: %v", i, value[0], err)
+		}
+		log, err := rlp.EncodeToBytes(logProof.Log())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := receipt.Logs[len(receipt.Logs)-1]; !bytes.Equal(log, expected) {
+			t.Fatalf("proven log of receipt %d does not match, expected: %x, got: %x", i, expected, log)
+		}
+		if value[0] <= 0x7f {
+			provenTyped++
+		}
+	}
+	if provenTyped == 0 {
+		t.Fatalf("no logs of typed receipts were proven")
+	}
+}
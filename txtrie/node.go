@@ -6,10 +6,12 @@ package txtrie
 // taken from
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
 
+	"github.com/ChainSafe/chainbridge-ethereum-trie/txtrie/trienode"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/rlp"
 )
@@ -145,8 +147,8 @@ func decodeShort(hash, elems []byte) (node, error) {
 		return nil, err
 	}
 	flag := nodeFlag{hash: hash}
-	key := compactToHex(kbuf)
-	if hasTerm(key) {
+	key := trienode.CompactToHex(kbuf)
+	if trienode.HasTerm(key) {
 		// value node
 		val, _, err := rlp.SplitString(rest)
 		if err != nil {
@@ -229,61 +231,36 @@ func (err *decodeError) Error() string {
 	return fmt.Sprintf("%v (decode path: %s)", err.what, strings.Join(err.stack, "<-"))
 }
 
-func compactToHex(compact []byte) []byte {
-	if len(compact) == 0 {
-		return compact
-	}
-	base := keybytesToHex(compact)
-	// delete terminator flag
-	if base[0] < 2 {
-		base = base[:len(base)-1]
-	}
-	// apply odd flag
-	chop := 2 - base[0]&1
-	return base[chop:]
-}
-
-func keybytesToHex(str []byte) []byte {
-	l := len(str)*2 + 1
-	var nibbles = make([]byte, l)
-	for i, b := range str {
-		nibbles[i*2] = b / 16
-		nibbles[i*2+1] = b % 16
-	}
-	nibbles[l-1] = 16
-	return nibbles
-}
-
-func hasTerm(s []byte) bool {
-	return s[len(s)-1] == 16
-}
-
-func get(tn node, key []byte, skipResolved bool) ([]byte, node) {
+// get follows key from tn, it returns an error instead of panicking on nodes it can't walk
+func get(tn node, key []byte, skipResolved bool) ([]byte, node, error) {
 	for {
 		switch n := tn.(type) {
 		case *shortNode:
 			if len(key) < len(n.Key) || !bytes.Equal(n.Key, key[:len(n.Key)]) {
-				return nil, nil
+				return nil, nil, nil
 			}
 			tn = n.Val
 			key = key[len(n.Key):]
 			if !skipResolved {
-				return key, tn
+				return key, tn, nil
 			}
 		case *fullNode:
+			if len(key) == 0 || key[0] >= byte(len(n.Children)) {
+				return nil, nil, errors.New("key ends at a full node")
+			}
 			tn = n.Children[key[0]]
 			key = key[1:]
 			if !skipResolved {
-				return key, tn
+				return key, tn, nil
 			}
 		case hashNode:
-			return key, n
+			return key, n, nil
 		case nil:
-			return key, nil
+			return key, nil, nil
 		case valueNode:
-			return nil, n
+			return nil, n, nil
 		default:
-			panic(fmt.Sprintf("%T: invalid node: %v", tn, tn))
+			return nil, nil, fmt.Errorf("%T: invalid node: %v", tn, tn)
 		}
 	}
 }
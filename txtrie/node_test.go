@@ -87,3 +87,20 @@ func TestDecodeFullNode(t *testing.T) {
 		t.Fatalf("decode full node err: %v", err)
 	}
 }
+
+func TestDecodeShortNodeEmptyKey(t *testing.T) {
+	root, proofDb := newHandmadeProof(t, []byte{0xc2, 0x80, 0x80})
+	exists, err := VerifyProof(root, []byte{0x01}, proofDb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exists {
+		t.Fatalf("short node without child verified as existing")
+	}
+}
+
+func TestGetEmptyKeyAtFullNode(t *testing.T) {
+	if _, _, err := get(&fullNode{}, []byte{}, true); err == nil {
+		t.Fatalf("expected empty key at a full node to fail")
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package txtrie
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// DefaultProofCacheEntries is the number of (root, key) pairs a proof cache holds unless configured otherwise
+const DefaultProofCacheEntries = 4096
+
+// ProofCacheStats holds the counters of a proof cache
+type ProofCacheStats struct {
+	Hits      uint64 // retrievals served from the cache
+	Misses    uint64 // retrievals that had to build the proof
+	Evictions uint64 // entries dropped to stay within the size limit
+	Entries   int    // number of cached (root, key) pairs
+}
+
+// proofCacheEntry holds the cached proofs of a single key, either may be nil
+type proofCacheEntry struct {
+	root    common.Hash
+	key     string
+	proof   []proofDBEntry
+	encoded []byte
+}
+
+// proofCache is an LRU cache of proofs by root and key, grouped by root so a trie can be invalidated at once
+type proofCache struct {
+	entries    map[common.Hash]map[string]*list.Element
+	order      *list.List // of *proofCacheEntry, most recently used first
+	maxEntries int
+	hits       uint64
+	misses     uint64
+	evictions  uint64
+	lock       sync.Mutex
+}
+
+func newProofCache(maxEntries int) *proofCache {
+	return &proofCache{
+		entries:    make(map[common.Hash]map[string]*list.Element),
+		order:      list.New(),
+		maxEntries: maxEntries,
+	}
+}
+
+// lookup passes the entry for root and key to found and, if count is set, counts a hit if it returns true
+func (c *proofCache) lookup(root common.Hash, key []byte, count bool, found func(*proofCacheEntry) bool) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	elem := c.entries[root][string(key)]
+	hit := elem != nil && found(elem.Value.(*proofCacheEntry))
+	if hit {
+		c.order.MoveToFront(elem)
+	}
+	if count {
+		if hit {
+			c.hits++
+		} else {
+			c.misses++
+		}
+	}
+	return hit
+}
+
+// proof returns a copy of the cached proof for root and key, or nil
+func (c *proofCache) proof(root common.Hash, key []byte, count bool) *ProofDatabase {
+	var entries []proofDBEntry
+	if !c.lookup(root, key, count, func(entry *proofCacheEntry) bool {
+		entries = entry.proof
+		return entries != nil
+	}) {
+		return nil
+	}
+
+	proofDb := NewProofDatabase()
+	proofDb.replace(entries)
+	return proofDb
+}
+
+// encodedProof returns a copy of the cached encoded proof for root and key, or nil
+func (c *proofCache) encodedProof(root common.Hash, key []byte) []byte {
+	var encoded []byte
+	if !c.lookup(root, key, true, func(entry *proofCacheEntry) bool {
+		encoded = entry.encoded
+		return encoded != nil
+	}) {
+		return nil
+	}
+	return common.CopyBytes(encoded)
+}
+
+// entry returns the entry for root and key, creating it and evicting the least recently used entries
+// if needed, the caller must hold the lock
+func (c *proofCache) entry(root common.Hash, key []byte) *proofCacheEntry {
+	keys := c.entries[root]
+	if keys == nil {
+		keys = make(map[string]*list.Element)
+		c.entries[root] = keys
+	}
+	if elem := keys[string(key)]; elem != nil {
+		c.order.MoveToFront(elem)
+		return elem.Value.(*proofCacheEntry)
+	}
+
+	entry := &proofCacheEntry{root: root, key: string(key)}
+	keys[string(key)] = c.order.PushFront(entry)
+	for c.order.Len() > c.maxEntries {
+		c.remove(c.order.Back())
+		c.evictions++
+	}
+	return entry
+}
+
+// remove drops the entry held by elem, the caller must hold the lock
+func (c *proofCache) remove(elem *list.Element) {
+	entry := c.order.Remove(elem).(*proofCacheEntry)
+	keys := c.entries[entry.root]
+	delete(keys, entry.key)
+	if len(keys) == 0 {
+		delete(c.entries, entry.root)
+	}
+}
+
+// storeProof caches a copy of proofDb for root and key
+func (c *proofCache) storeProof(root common.Hash, key []byte, proofDb *ProofDatabase) error {
+	entries, err := proofDb.sortedEntries()
+	if err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.entry(root, key).proof = entries
+	return nil
+}
+
+// storeEncodedProof caches a copy of encoded for root and key
+func (c *proofCache) storeEncodedProof(root common.Hash, key []byte, encoded []byte) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.entry(root, key).encoded = common.CopyBytes(encoded)
+}
+
+// invalidate drops all cached proofs for root
+func (c *proofCache) invalidate(root common.Hash) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for _, elem := range c.entries[root] {
+		c.order.Remove(elem)
+	}
+	delete(c.entries, root)
+}
+
+func (c *proofCache) stats() ProofCacheStats {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return ProofCacheStats{Hits: c.hits, Misses: c.misses, Evictions: c.evictions, Entries: c.order.Len()}
+}
+
+// EnableProofCache makes TxTries t cache the proofs it retrieves by root and key. At most maxEntries
+// (root, key) pairs are kept, or DefaultProofCacheEntries if maxEntries is not positive, and the least
+// recently used ones are evicted first. Deleting a trie drops its cached proofs. Calling it again
+// has no effect.
+func (t *TxTries) EnableProofCache(maxEntries int) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if maxEntries <= 0 {
+		maxEntries = DefaultProofCacheEntries
+	}
+	if t.cache == nil {
+		t.cache = newProofCache(maxEntries)
+	}
+}
+
+// ProofCacheStats returns the counters of the proof cache, which are zero if it is not enabled. Every
+// RetrieveProof and RetrieveEncodedProof call for a stored trie counts exactly one hit or miss.
+func (t *TxTries) ProofCacheStats() ProofCacheStats {
+	t.lock.RLock()
+	cache := t.cache
+	t.lock.RUnlock()
+
+	if cache == nil {
+		return ProofCacheStats{}
+	}
+	return cache.stats()
+}
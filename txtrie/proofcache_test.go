@@ -0,0 +1,161 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package txtrie
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+func TestProofCacheHitsAndInvalidation(t *testing.T) {
+	vals := GetTransactions2()
+	expectedRoot, err := computeEthReferenceTrieHash(vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	txTries := NewTxTries()
+	txTries.EnableProofCache(0)
+	err = addTrie(txTries, expectedRoot, vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := rlp.EncodeToBytes(uint(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := txTries.RetrieveProof(expectedRoot, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// modifying a returned proof must not affect the cached one
+	if err := first.Put([]byte("extra"), []byte("node")); err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := txTries.RetrieveProof(expectedRoot, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Len() != first.Len()-1 {
+		t.Fatalf("cached proof was modified, expected: %d nodes, got: %d", first.Len()-1, second.Len())
+	}
+	exists, err := VerifyProof(expectedRoot, key, second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !exists {
+		t.Fatalf("not able to verify cached proof")
+	}
+
+	stats := txTries.ProofCacheStats()
+	if stats.Hits != 1 || stats.Misses != 1 || stats.Entries != 1 {
+		t.Fatalf("unexpected cache stats after proof lookups: %+v", stats)
+	}
+
+	encoded, err := txTries.RetrieveEncodedProof(expectedRoot, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cached, err := txTries.RetrieveEncodedProof(expectedRoot, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(encoded, cached) {
+		t.Fatalf("cached encoded proof differs, expected: %x, got: %x", encoded, cached)
+	}
+
+	// the first encoded lookup misses, the second one hits
+	stats = txTries.ProofCacheStats()
+	if stats.Hits != 2 || stats.Misses != 2 || stats.Entries != 1 {
+		t.Fatalf("unexpected cache stats after encoded lookups: %+v", stats)
+	}
+
+	txTries.DeleteTrie(expectedRoot)
+	if stats = txTries.ProofCacheStats(); stats.Entries != 0 {
+		t.Fatalf("cache not invalidated on delete: %+v", stats)
+	}
+	if _, err := txTries.RetrieveProof(expectedRoot, key); err == nil {
+		t.Fatalf("expected proof of deleted trie to fail")
+	}
+}
+
+func TestProofCacheDisabled(t *testing.T) {
+	txTries, _, key := retrieveTestProof(t, 0)
+	if _, err := txTries.RetrieveEncodedProof(txTries.txRoots[0], key); err != nil {
+		t.Fatal(err)
+	}
+
+	if stats := txTries.ProofCacheStats(); stats != (ProofCacheStats{}) {
+		t.Fatalf("unexpected stats without cache: %+v", stats)
+	}
+}
+
+func TestProofCacheUnknownRoot(t *testing.T) {
+	txTries := NewTxTries()
+	txTries.EnableProofCache(0)
+
+	key, err := rlp.EncodeToBytes(uint(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := txTries.RetrieveProof(emptyRoot, key); err == nil {
+		t.Fatal("expected proof of unknown root to fail")
+	}
+	if _, err := txTries.RetrieveEncodedProof(emptyRoot, key); err == nil {
+		t.Fatal("expected encoded proof of unknown root to fail")
+	}
+
+	if stats := txTries.ProofCacheStats(); stats != (ProofCacheStats{}) {
+		t.Fatalf("lookups of unknown roots were counted: %+v", stats)
+	}
+}
+
+func TestProofCacheEviction(t *testing.T) {
+	vals := GetTransactions2()
+	expectedRoot, err := computeEthReferenceTrieHash(vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	txTries := NewTxTries()
+	txTries.EnableProofCache(2)
+	err = addTrie(txTries, expectedRoot, vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := make([][]byte, 3)
+	for i := range keys {
+		if keys[i], err = rlp.EncodeToBytes(uint(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// key 0 is used again before key 2 is added, so key 1 is the least recently used one
+	for _, i := range []int{0, 1, 0, 2} {
+		if _, err := txTries.RetrieveProof(expectedRoot, keys[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stats := txTries.ProofCacheStats()
+	if stats.Hits != 1 || stats.Misses != 3 || stats.Evictions != 1 || stats.Entries != 2 {
+		t.Fatalf("unexpected cache stats after eviction: %+v", stats)
+	}
+
+	if _, err := txTries.RetrieveProof(expectedRoot, keys[0]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := txTries.RetrieveProof(expectedRoot, keys[1]); err != nil {
+		t.Fatal(err)
+	}
+	stats = txTries.ProofCacheStats()
+	if stats.Hits != 2 || stats.Misses != 4 || stats.Evictions != 2 || stats.Entries != 2 {
+		t.Fatalf("unexpected cache stats after lookups: %+v", stats)
+	}
+}
@@ -5,18 +5,30 @@ package txtrie
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
 )
 
+// ProofDatabase is an in memory ethdb.KeyValueStore holding the nodes of a proof
 type ProofDatabase struct {
-	db   map[string][]byte
-	lock sync.RWMutex
+	db       map[string][]byte
+	size     int  // total length of the stored keys and values
+	zeroCopy bool // whether Get returns the stored slices instead of copies
+	lock     sync.RWMutex
 }
 
+var _ ethdb.KeyValueStore = (*ProofDatabase)(nil)
+
 // NewProofDatabase returns a wrapped map
 func NewProofDatabase() *ProofDatabase {
 	db := &ProofDatabase{
@@ -32,13 +44,24 @@ func (db *ProofDatabase) Close() error {
 	defer db.lock.Unlock()
 
 	db.db = nil
+	db.size = 0
 	return nil
 }
 
-// Has checks if key exists in ProofDatabase db
-func (db *ProofDatabase) Has(key []byte) (bool, error) {
+// SetZeroCopyReads makes Get return the stored values without copying them. This is unsafe:
+// callers must not modify returned values. It avoids an allocation per read for verifiers,
+// which only decode the nodes they read.
+func (db *ProofDatabase) SetZeroCopyReads(enabled bool) {
 	db.lock.Lock()
 	defer db.lock.Unlock()
+
+	db.zeroCopy = enabled
+}
+
+// Has checks if key exists in ProofDatabase db
+func (db *ProofDatabase) Has(key []byte) (bool, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
 	if db.db == nil {
 		return false, errors.New("database does not exist")
 	}
@@ -48,12 +71,15 @@ func (db *ProofDatabase) Has(key []byte) (bool, error) {
 
 // Get retrieves value associated with key in ProofDatabase db, and checks for existance
 func (db *ProofDatabase) Get(key []byte) ([]byte, error) {
-	db.lock.Lock()
-	defer db.lock.Unlock()
+	db.lock.RLock()
+	defer db.lock.RUnlock()
 	if db.db == nil {
 		return nil, errors.New("database does not exist")
 	}
 	if val, exists := db.db[string(key)]; exists {
+		if db.zeroCopy {
+			return val, nil
+		}
 		return common.CopyBytes(val), nil
 	}
 
@@ -67,7 +93,7 @@ func (db *ProofDatabase) Put(key []byte, value []byte) error {
 	if db.db == nil {
 		return errors.New("database does not exist")
 	}
-	db.db[string(key)] = common.CopyBytes(value)
+	db.put(key, value)
 	return nil
 }
 
@@ -79,49 +105,321 @@ func (db *ProofDatabase) Delete(key []byte) error {
 	if db.db == nil {
 		return errors.New("database does not exist")
 	}
-	delete(db.db, string(key))
+	db.delete(key)
 	return nil
 }
 
-// Encodes a proof Database to a format parsable by the on chain contract
-func encodeProofDB(rootHash common.Hash, key []byte, proofDb *ProofDatabase) ([]byte, error) {
-	var proofNodes proof
-	var encodedProof = bytes.NewBuffer([]byte{})
-	key = keybytesToHex(key)
-	wantHash := rootHash
-
-	// we want to repeat until we have reached the desired value node
-	for i := 0; ; i++ {
-		// retrieve target node from proofDB
-		buf, _ := proofDb.Get(wantHash[:])
-		if buf == nil {
-			return nil, fmt.Errorf("proof node %d (hash %064x) missing", i, wantHash)
+// put stores a copy of value at key, the caller must hold the write lock
+func (db *ProofDatabase) put(key []byte, value []byte) {
+	db.delete(key)
+	db.db[string(key)] = common.CopyBytes(value)
+	db.size += len(key) + len(value)
+}
+
+// delete removes key, the caller must hold the write lock
+func (db *ProofDatabase) delete(key []byte) {
+	if old, exists := db.db[string(key)]; exists {
+		db.size -= len(key) + len(old)
+		delete(db.db, string(key))
+	}
+}
+
+// Len returns the number of entries in ProofDatabase db
+func (db *ProofDatabase) Len() int {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	return len(db.db)
+}
+
+// Size returns the total length of the keys and values stored in ProofDatabase db
+func (db *ProofDatabase) Size() int {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	return db.size
+}
+
+// Stat returns the "count" or "size" property of ProofDatabase db
+func (db *ProofDatabase) Stat(property string) (string, error) {
+	switch property {
+	case "count":
+		return strconv.Itoa(db.Len()), nil
+	case "size":
+		return strconv.Itoa(db.Size()), nil
+	default:
+		return "", errors.New("unknown property")
+	}
+}
+
+// Compact is a no-op, an in memory database doesn't waste space
+func (db *ProofDatabase) Compact(start []byte, limit []byte) error {
+	return nil
+}
+
+// NewBatch creates a batch buffering writes to ProofDatabase db until Write is called
+func (db *ProofDatabase) NewBatch() ethdb.Batch {
+	return &proofDBBatch{db: db}
+}
+
+// NewIterator creates an iterator over the entries of ProofDatabase db with the given prefix,
+// starting at the key prefix+start. The iterator works on a snapshot of the database.
+func (db *ProofDatabase) NewIterator(prefix []byte, start []byte) ethdb.Iterator {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	var (
+		pr     = string(prefix)
+		st     = string(append(common.CopyBytes(prefix), start...))
+		keys   = make([]string, 0, len(db.db))
+		values = make([][]byte, 0, len(db.db))
+	)
+	for key := range db.db {
+		if strings.HasPrefix(key, pr) && key >= st {
+			keys = append(keys, key)
 		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		values = append(values, db.db[key])
+	}
 
-		n, err := decodeNode(wantHash[:], buf)
-		if err != nil {
-			return nil, fmt.Errorf("bad proof node %d: %v", i, err)
+	return &proofDBIterator{keys: keys, values: values}
+}
+
+// proofDBWrite is a write queued in a proofDBBatch
+type proofDBWrite struct {
+	key    []byte
+	value  []byte
+	delete bool
+}
+
+// proofDBBatch is a write-only batch that commits changes to its ProofDatabase when Write is called
+type proofDBBatch struct {
+	db     *ProofDatabase
+	writes []proofDBWrite
+	size   int
+}
+
+// Put queues the insertion of value at key
+func (b *proofDBBatch) Put(key []byte, value []byte) error {
+	b.writes = append(b.writes, proofDBWrite{common.CopyBytes(key), common.CopyBytes(value), false})
+	b.size += len(value)
+	return nil
+}
+
+// Delete queues the removal of key
+func (b *proofDBBatch) Delete(key []byte) error {
+	b.writes = append(b.writes, proofDBWrite{common.CopyBytes(key), nil, true})
+	b.size++
+	return nil
+}
+
+// ValueSize returns the amount of data queued up for writing
+func (b *proofDBBatch) ValueSize() int {
+	return b.size
+}
+
+// Write applies the queued writes to the database
+func (b *proofDBBatch) Write() error {
+	b.db.lock.Lock()
+	defer b.db.lock.Unlock()
+
+	if b.db.db == nil {
+		return errors.New("database does not exist")
+	}
+	for _, write := range b.writes {
+		if write.delete {
+			b.db.delete(write.key)
+			continue
 		}
+		b.db.put(write.key, write.value)
+	}
+	return nil
+}
+
+// Reset discards the queued writes so the batch can be reused
+func (b *proofDBBatch) Reset() {
+	b.writes = b.writes[:0]
+	b.size = 0
+}
 
-		proofNodes = append(proofNodes, n)
-
-		// we want to retrieve the next node on the key path
-		keyrest, cld := get(n, key, true)
-		switch cld := cld.(type) {
-		case nil:
-			// The trie doesn't contain the key
-			return nil, nil
-		case hashNode:
-			// There are more elements in the proof to retrieve
-			key = keyrest
-			copy(wantHash[:], cld)
-		case valueNode:
-			// We have reached the desired value node
-			err := proofNodes.EncodeRLP(encodedProof)
-			if err != nil {
-				return nil, err
+// Replay applies the queued writes to w
+func (b *proofDBBatch) Replay(w ethdb.KeyValueWriter) error {
+	for _, write := range b.writes {
+		if write.delete {
+			if err := w.Delete(write.key); err != nil {
+				return err
 			}
-			return encodedProof.Bytes(), nil
+			continue
+		}
+		if err := w.Put(write.key, write.value); err != nil {
+			return err
 		}
 	}
+	return nil
+}
+
+// proofDBIterator walks over a sorted snapshot of the entries of a ProofDatabase
+type proofDBIterator struct {
+	inited bool
+	keys   []string
+	values [][]byte
+}
+
+// Next moves the iterator to the next entry and reports whether there is one
+func (it *proofDBIterator) Next() bool {
+	if !it.inited {
+		it.inited = true
+		return len(it.keys) > 0
+	}
+	if len(it.keys) > 0 {
+		it.keys = it.keys[1:]
+		it.values = it.values[1:]
+	}
+	return len(it.keys) > 0
+}
+
+// Error returns nil, iterating over a snapshot cannot fail
+func (it *proofDBIterator) Error() error {
+	return nil
+}
+
+// Key returns the key of the current entry, or nil if done
+func (it *proofDBIterator) Key() []byte {
+	if len(it.keys) > 0 {
+		return []byte(it.keys[0])
+	}
+	return nil
+}
+
+// Value returns the value of the current entry, or nil if done
+func (it *proofDBIterator) Value() []byte {
+	if len(it.values) > 0 {
+		return it.values[0]
+	}
+	return nil
+}
+
+// Release drops the snapshot held by the iterator
+func (it *proofDBIterator) Release() {
+	it.keys, it.values = nil, nil
+}
+
+// proofDBEntry is a key value pair of the binary encoding of a ProofDatabase
+type proofDBEntry struct {
+	Key   []byte
+	Value []byte
+}
+
+// sortedEntries returns the content of ProofDatabase db sorted by key
+func (db *ProofDatabase) sortedEntries() ([]proofDBEntry, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+	if db.db == nil {
+		return nil, errors.New("database does not exist")
+	}
+
+	entries := make([]proofDBEntry, 0, len(db.db))
+	for key, value := range db.db {
+		entries = append(entries, proofDBEntry{Key: []byte(key), Value: value})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].Key, entries[j].Key) < 0
+	})
+	return entries, nil
+}
+
+// replace swaps the content of ProofDatabase db with entries
+func (db *ProofDatabase) replace(entries []proofDBEntry) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	db.db = make(map[string][]byte, len(entries))
+	db.size = 0
+	for _, entry := range entries {
+		db.put(entry.Key, entry.Value)
+	}
+}
+
+// MarshalJSON encodes ProofDatabase db as a JSON object mapping hex keys to hex values
+func (db *ProofDatabase) MarshalJSON() ([]byte, error) {
+	entries, err := db.sortedEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	// encoding/json writes map keys in sorted order, so identical proofs serialize identically
+	content := make(map[string]hexutil.Bytes, len(entries))
+	for _, entry := range entries {
+		content[hexutil.Encode(entry.Key)] = entry.Value
+	}
+	return json.Marshal(content)
+}
+
+// UnmarshalJSON replaces the content of ProofDatabase db with a JSON object of hex keys and values
+func (db *ProofDatabase) UnmarshalJSON(input []byte) error {
+	var content map[string]hexutil.Bytes
+	if err := json.Unmarshal(input, &content); err != nil {
+		return err
+	}
+
+	entries := make([]proofDBEntry, 0, len(content))
+	for hexKey, value := range content {
+		key, err := hexutil.Decode(hexKey)
+		if err != nil {
+			return fmt.Errorf("invalid key %q: %v", hexKey, err)
+		}
+		entries = append(entries, proofDBEntry{Key: key, Value: value})
+	}
+
+	db.replace(entries)
+	return nil
+}
+
+// MarshalBinary encodes ProofDatabase db as an rlp list of key value pairs sorted by key
+func (db *ProofDatabase) MarshalBinary() ([]byte, error) {
+	entries, err := db.sortedEntries()
+	if err != nil {
+		return nil, err
+	}
+	return rlp.EncodeToBytes(entries)
+}
+
+// UnmarshalBinary replaces the content of ProofDatabase db with an encoding produced by MarshalBinary
+func (db *ProofDatabase) UnmarshalBinary(data []byte) error {
+	var entries []proofDBEntry
+	if err := rlp.DecodeBytes(data, &entries); err != nil {
+		return err
+	}
+
+	for i := 1; i < len(entries); i++ {
+		if bytes.Compare(entries[i-1].Key, entries[i].Key) >= 0 {
+			return errors.New("proof database entries are not sorted or contain duplicates")
+		}
+	}
+
+	db.replace(entries)
+	return nil
+}
+
+// Encodes a proof Database to a format parsable by the on chain contract
+func encodeProofDB(rootHash common.Hash, key []byte, proofDb *ProofDatabase) ([]byte, error) {
+	// the proof holds the hashed nodes up to the value node, or to the point where the trie proves the key absent
+	var proofNodes proof
+	_, err := walkPath(rootHash, key, dbLookup(proofDb), func(w *walkedNode) error {
+		if w.hash != nil {
+			proofNodes = append(proofNodes, w.node)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var encodedProof = bytes.NewBuffer([]byte{})
+	if err := proofNodes.EncodeRLP(encodedProof); err != nil {
+		return nil, err
+	}
+	return encodedProof.Bytes(), nil
 }
@@ -0,0 +1,307 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package txtrie
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/dbtest"
+	"github.com/ethereum/go-ethereum/rlp"
+	ethtrie "github.com/ethereum/go-ethereum/trie"
+)
+
+func retrieveTestProof(t *testing.T, index uint) (*TxTries, *ProofDatabase, []byte) {
+	vals := GetTransactions2()
+	expectedRoot, err := computeEthReferenceTrieHash(vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	txTries := NewTxTries()
+	err = addTrie(txTries, expectedRoot, vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := rlp.EncodeToBytes(index)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proofDb, err := txTries.RetrieveProof(expectedRoot, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return txTries, proofDb, key
+}
+
+func TestProofDatabaseJSONRoundTrip(t *testing.T) {
+	txTries, proofDb, key := retrieveTestProof(t, 1)
+	root := txTries.txRoots[0]
+
+	data, err := json.Marshal(proofDb)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := new(ProofDatabase)
+	if err := json.Unmarshal(data, decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	exists, err := VerifyProof(root, key, decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !exists {
+		t.Fatalf("not able to verify proof after json round trip")
+	}
+
+	reencoded, err := json.Marshal(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, reencoded) {
+		t.Fatalf("json round trip changed the encoding, expected: %s, got: %s", data, reencoded)
+	}
+
+	if err := json.Unmarshal([]byte(`{"zz":"0x00"}`), decoded); err == nil {
+		t.Fatalf("expected invalid hex key to fail")
+	}
+}
+
+func TestProofDatabaseBinaryRoundTrip(t *testing.T) {
+	txTries, proofDb, key := retrieveTestProof(t, 0)
+	root := txTries.txRoots[0]
+
+	data, err := proofDb.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := NewProofDatabase()
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+
+	exists, err := VerifyProof(root, key, decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !exists {
+		t.Fatalf("not able to verify proof after binary round trip")
+	}
+
+	unsorted, err := rlp.EncodeToBytes([]proofDBEntry{{Key: []byte{2}}, {Key: []byte{1}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := decoded.UnmarshalBinary(unsorted); err == nil {
+		t.Fatalf("expected unsorted entries to fail")
+	}
+}
+
+func TestProofDatabaseDeterministicEncoding(t *testing.T) {
+	_, proofDb, _ := retrieveTestProof(t, 2)
+
+	entries, err := proofDb.sortedEntries()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// insert the same nodes in reverse order
+	reversed := NewProofDatabase()
+	for i := len(entries) - 1; i >= 0; i-- {
+		if err := reversed.Put(entries[i].Key, entries[i].Value); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i := 0; i < 10; i++ {
+		expected, err := proofDb.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := reversed.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(expected, got) {
+			t.Fatalf("identical proofs serialized differently, expected: %x, got: %x", expected, got)
+		}
+
+		expectedJSON, err := json.Marshal(proofDb)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gotJSON, err := json.Marshal(reversed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(expectedJSON, gotJSON) {
+			t.Fatalf("identical proofs serialized differently, expected: %s, got: %s", expectedJSON, gotJSON)
+		}
+	}
+}
+
+func TestProofDatabaseSuite(t *testing.T) {
+	dbtest.TestDatabaseSuite(t, func() ethdb.KeyValueStore {
+		return NewProofDatabase()
+	})
+}
+
+func TestProofDatabaseSizeAccounting(t *testing.T) {
+	db := NewProofDatabase()
+
+	if err := db.Put([]byte("key"), []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Put([]byte("key"), []byte("longer value")); err != nil {
+		t.Fatal(err)
+	}
+
+	batch := db.NewBatch()
+	if err := batch.Put([]byte("other"), []byte("v")); err != nil {
+		t.Fatal(err)
+	}
+	if err := batch.Delete([]byte("missing")); err != nil {
+		t.Fatal(err)
+	}
+	if err := batch.Write(); err != nil {
+		t.Fatal(err)
+	}
+
+	if db.Len() != 2 || db.Size() != len("key")+len("longer value")+len("other")+len("v") {
+		t.Fatalf("unexpected size accounting, count: %d, size: %d", db.Len(), db.Size())
+	}
+	if size, err := db.Stat("size"); err != nil || size != "21" {
+		t.Fatalf("unexpected size stat: %s (%v)", size, err)
+	}
+
+	if err := db.Delete([]byte("key")); err != nil {
+		t.Fatal(err)
+	}
+	if db.Size() != len("other")+len("v") {
+		t.Fatalf("unexpected size after delete, expected: %d, got: %d", 6, db.Size())
+	}
+
+	if _, err := db.Stat("unknown"); err == nil {
+		t.Fatalf("expected unknown property to fail")
+	}
+}
+
+func TestProofDatabaseAsTrieStore(t *testing.T) {
+	vals := GetTransactions1()
+	expectedRoot, err := computeEthReferenceTrieHash(vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := NewProofDatabase()
+	trieDb := ethtrie.NewDatabase(db)
+	trie, err := ethtrie.New(emptyRoot, trieDb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := updateTrie(trie, vals, expectedRoot); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := trie.Commit(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := trieDb.Commit(expectedRoot, false, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := ethtrie.New(expectedRoot, ethtrie.NewDatabase(db))
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := rlp.EncodeToBytes(uint(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, err := reopened.TryGet(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := rlp.EncodeToBytes(vals[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(value, expected) {
+		t.Fatalf("unexpected value read back from trie, expected: %x, got: %x", expected, value)
+	}
+}
+
+func TestProofDatabaseZeroCopyReads(t *testing.T) {
+	db := NewProofDatabase()
+	if err := db.Put([]byte("key"), []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+
+	copied, err := db.Get([]byte("key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	copied[0] = 'x'
+	if stored, _ := db.Get([]byte("key")); string(stored) != "value" {
+		t.Fatalf("modifying a copied read changed the database: %s", stored)
+	}
+
+	db.SetZeroCopyReads(true)
+	first, err := db.Get([]byte("key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := db.Get([]byte("key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if &first[0] != &second[0] {
+		t.Fatalf("zero copy reads returned different slices")
+	}
+}
+
+func benchmarkParallelVerifyProof(b *testing.B, zeroCopy bool) {
+	vals := GetTransactions2()
+	expectedRoot, err := computeEthReferenceTrieHash(vals)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	txTries := NewTxTries()
+	if err := txTries.CreateNewTrie(expectedRoot, vals); err != nil {
+		b.Fatal(err)
+	}
+
+	key, err := rlp.EncodeToBytes(uint(1))
+	if err != nil {
+		b.Fatal(err)
+	}
+	proofDb, err := txTries.RetrieveProof(expectedRoot, key)
+	if err != nil {
+		b.Fatal(err)
+	}
+	proofDb.SetZeroCopyReads(zeroCopy)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if exists, err := VerifyProof(expectedRoot, key, proofDb); err != nil || !exists {
+				b.Errorf("not able to verify proof: %v", err)
+				return
+			}
+		}
+	})
+}
+
+func BenchmarkParallelVerifyProof(b *testing.B) {
+	b.Run("copy", func(b *testing.B) { benchmarkParallelVerifyProof(b, false) })
+	b.Run("zerocopy", func(b *testing.B) { benchmarkParallelVerifyProof(b, true) })
+}
@@ -0,0 +1,133 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package txtrie
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ChainSafe/chainbridge-ethereum-trie/txtrie/trienode"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// NodeKind identifies the type of a trie node
+type NodeKind uint8
+
+// Node kinds of a Merkle Patricia trie
+const (
+	BranchNode NodeKind = iota + 1
+	ExtensionNode
+	LeafNode
+)
+
+func (k NodeKind) String() string {
+	switch k {
+	case BranchNode:
+		return "branch"
+	case ExtensionNode:
+		return "extension"
+	case LeafNode:
+		return "leaf"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(k))
+	}
+}
+
+// ProofStep describes a single node walked while following a key through a proof.
+// Nibbles are stored one per byte, the terminator is 16.
+type ProofStep struct {
+	Kind      NodeKind
+	Hash      common.Hash // hash of the node, zero for nodes embedded in their parent
+	Embedded  bool
+	Consumed  []byte // nibbles of the key matched by the node
+	Remaining []byte // nibbles of the key left after the node
+	Child     int    // index of the selected branch child, -1 for extension and leaf nodes
+	Node      string // the decoded node
+}
+
+// ProofPath is the list of nodes walked while following Key from Root
+type ProofPath struct {
+	Root  common.Hash
+	Key   []byte
+	Steps []ProofStep
+	Value []byte // the value at Key, nil if the proof shows Key does not exist
+}
+
+// TraceProof follows key from root through the nodes of proofDb and records every node it walks
+func TraceProof(root common.Hash, key []byte, proofDb *ProofDatabase) (*ProofPath, error) {
+	path := &ProofPath{Root: root, Key: common.CopyBytes(key)}
+
+	value, err := walkPath(root, key, dbLookup(proofDb), func(w *walkedNode) error {
+		step := ProofStep{Child: -1, Node: strings.TrimSpace(w.node.fstring(""))}
+		if w.hash != nil {
+			step.Hash = common.BytesToHash(w.hash)
+		} else {
+			step.Embedded = true
+		}
+
+		switch n := w.node.(type) {
+		case *fullNode:
+			step.Kind = BranchNode
+			step.Child = int(w.key[0])
+		case *shortNode:
+			step.Kind = ExtensionNode
+			if trienode.HasTerm(n.Key) {
+				step.Kind = LeafNode
+			}
+		}
+
+		rest := w.rest
+		if rest == nil && w.child == nil {
+			// a short node not matching the key
+			rest = w.key
+		}
+		step.Consumed = common.CopyBytes(w.key[:len(w.key)-len(rest)])
+		step.Remaining = common.CopyBytes(rest)
+		path.Steps = append(path.Steps, step)
+		return nil
+	})
+	path.Value = common.CopyBytes(value)
+	return path, err
+}
+
+// Exists reports whether the traced key is present in the trie
+func (p *ProofPath) Exists() bool {
+	return p.Value != nil
+}
+
+// String renders the path with one line per node followed by the decoded node
+func (p *ProofPath) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "root %x key %x\n", p.Root, p.Key)
+	for i, step := range p.Steps {
+		ref := fmt.Sprintf("%x", step.Hash)
+		if step.Embedded {
+			ref = "embedded"
+		}
+		fmt.Fprintf(&b, "%2d: %-9s %s consumed [%s] remaining [%s]", i, step.Kind, ref, nibbleString(step.Consumed), nibbleString(step.Remaining))
+		if step.Child >= 0 {
+			fmt.Fprintf(&b, " child %d", step.Child)
+		}
+		fmt.Fprintf(&b, "\n    %s\n", strings.ReplaceAll(step.Node, "\n", "\n    "))
+	}
+	if p.Exists() {
+		fmt.Fprintf(&b, "value %x\n", p.Value)
+	} else {
+		b.WriteString("key does not exist\n")
+	}
+	return b.String()
+}
+
+// nibbleString renders nibbles as hex digits, with T for the terminator
+func nibbleString(nibbles []byte) string {
+	var b strings.Builder
+	for _, n := range nibbles {
+		if n == 16 {
+			b.WriteByte('T')
+		} else {
+			fmt.Fprintf(&b, "%x", n)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,80 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package txtrie
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ChainSafe/chainbridge-ethereum-trie/txtrie/trienode"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+func TestTraceProof(t *testing.T) {
+	txTries, proofDb, key := retrieveTestProof(t, 1)
+	root := txTries.txRoots[0]
+
+	path, err := TraceProof(root, key, proofDb)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected, err := verifyProof(root, key, proofDb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !path.Exists() || !bytes.Equal(path.Value, expected) {
+		t.Fatalf("traced value does not match, expected: %x, got: %x", expected, path.Value)
+	}
+
+	if path.Steps[0].Hash != root || path.Steps[0].Embedded {
+		t.Fatalf("first step is not the root, expected: %x, got: %x", root, path.Steps[0].Hash)
+	}
+
+	var consumed []byte
+	for i, step := range path.Steps {
+		consumed = append(consumed, step.Consumed...)
+		if step.Kind == BranchNode && (step.Child < 0 || int(step.Consumed[0]) != step.Child) {
+			t.Fatalf("step %d selected child %d but consumed %v", i, step.Child, step.Consumed)
+		}
+	}
+	if !bytes.Equal(consumed, trienode.KeybytesToHex(key)) {
+		t.Fatalf("steps did not consume the key, expected: %v, got: %v", trienode.KeybytesToHex(key), consumed)
+	}
+
+	dump := path.String()
+	if !strings.Contains(dump, "branch") || !strings.Contains(dump, "value ") {
+		t.Fatalf("unexpected dump:\n%s", dump)
+	}
+}
+
+func TestTraceProofMissingKey(t *testing.T) {
+	txTries, _, _ := retrieveTestProof(t, 0)
+	root := txTries.txRoots[0]
+
+	key, err := rlp.EncodeToBytes(uint(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	proofDb, err := txTries.RetrieveProof(root, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path, err := TraceProof(root, key, proofDb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path.Exists() || len(path.Steps) == 0 {
+		t.Fatalf("expected absence proof, got:\n%s", path)
+	}
+	if !strings.Contains(path.String(), "key does not exist") {
+		t.Fatalf("unexpected dump:\n%s", path)
+	}
+
+	if _, err := TraceProof(root, key, NewProofDatabase()); err == nil {
+		t.Fatalf("expected trace without nodes to fail")
+	}
+}
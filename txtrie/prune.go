@@ -0,0 +1,61 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package txtrie
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// PruneReport describes how the nodes of a ProofDatabase relate to the path of a key
+type PruneReport struct {
+	Used    []common.Hash // hashes of the nodes on the path, starting at the root
+	Unused  [][]byte      // keys of the stored entries not on the path, sorted
+	Missing []common.Hash // hashes of the nodes on the path that are not stored, the walk stops at the first one
+}
+
+// Complete reports whether every node on the path was found
+func (r *PruneReport) Complete() bool {
+	return len(r.Missing) == 0
+}
+
+// Minimal reports whether the database held exactly the nodes on the path
+func (r *PruneReport) Minimal() bool {
+	return r.Complete() && len(r.Unused) == 0
+}
+
+// PruneProof returns a new ProofDatabase holding only the nodes of proofDb on the path of key
+// in the trie with root root, along with a report of the unused and missing nodes
+func PruneProof(proofDb *ProofDatabase, root common.Hash, key []byte) (*ProofDatabase, *PruneReport, error) {
+	pruned := NewProofDatabase()
+	report := &PruneReport{}
+
+	_, err := walkPath(root, key, dbLookup(proofDb), func(w *walkedNode) error {
+		if w.hash == nil {
+			return nil
+		}
+		hash := common.BytesToHash(w.hash)
+		report.Used = append(report.Used, hash)
+		return pruned.Put(hash[:], w.enc)
+	})
+	var missing *missingNodeError
+	if errors.As(err, &missing) {
+		report.Missing = append(report.Missing, missing.hash)
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	entries, err := proofDb.sortedEntries()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, entry := range entries {
+		if exists, _ := pruned.Has(entry.Key); !exists {
+			report.Unused = append(report.Unused, entry.Key)
+		}
+	}
+
+	return pruned, report, nil
+}
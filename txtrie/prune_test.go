@@ -0,0 +1,116 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package txtrie
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+func TestPruneMergedProofs(t *testing.T) {
+	vals := GetTransactions2()
+	expectedRoot, err := computeEthReferenceTrieHash(vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	txTries := NewTxTries()
+	err = addTrie(txTries, expectedRoot, vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// merge the proofs of every key into a single database
+	merged := NewProofDatabase()
+	for i := range vals {
+		key, err := rlp.EncodeToBytes(uint(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := txTries.txTries[expectedRoot].Prove(key, 0, merged); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	key, err := rlp.EncodeToBytes(uint(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := txTries.RetrieveProof(expectedRoot, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pruned, report, err := PruneProof(merged, expectedRoot, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !report.Complete() || report.Minimal() {
+		t.Fatalf("unexpected report for merged proofs: %+v", report)
+	}
+	if pruned.Len() != expected.Len() || len(report.Used) != expected.Len() {
+		t.Fatalf("unexpected pruned size, expected: %d, got: %d", expected.Len(), pruned.Len())
+	}
+	if len(report.Unused) != merged.Len()-pruned.Len() {
+		t.Fatalf("unexpected unused count, expected: %d, got: %d", merged.Len()-pruned.Len(), len(report.Unused))
+	}
+
+	exists, err := VerifyProof(expectedRoot, key, pruned)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !exists {
+		t.Fatalf("not able to verify pruned proof")
+	}
+
+	_, report, err = PruneProof(pruned, expectedRoot, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Minimal() {
+		t.Fatalf("pruned proof is not minimal: %+v", report)
+	}
+}
+
+func TestPruneMissingNode(t *testing.T) {
+	vals := GetTransactions2()
+	expectedRoot, err := computeEthReferenceTrieHash(vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	txTries := NewTxTries()
+	err = addTrie(txTries, expectedRoot, vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := rlp.EncodeToBytes(uint(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	proofDb, err := txTries.RetrieveProof(expectedRoot, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, report, err := PruneProof(proofDb, expectedRoot, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := report.Used[len(report.Used)-1]
+	if err := proofDb.Delete(last[:]); err != nil {
+		t.Fatal(err)
+	}
+
+	_, report, err = PruneProof(proofDb, expectedRoot, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Complete() || len(report.Missing) != 1 || report.Missing[0] != last {
+		t.Fatalf("missing node not reported: %+v", report)
+	}
+}
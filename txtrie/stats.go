@@ -0,0 +1,80 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package txtrie
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// TrieStats is the size of the encoded nodes of a stored trie, which doesn't depend on how the trie is
+// stored. BenchmarkTrieHeap measures the heap kept alive by full and compact tries.
+type TrieStats struct {
+	Root  common.Hash
+	Nodes int // number of hashed nodes, embedded nodes are counted as part of their parent
+	Bytes int // total length of the encoded nodes, including the values they hold
+}
+
+// TxTriesStats is the size of the encoded nodes of all tries stored in a TxTries
+type TxTriesStats struct {
+	Tries []TrieStats // in insertion order
+	Nodes int
+	Bytes int
+}
+
+// Stats returns the node count and encoded size of every stored trie and their totals. Stored tries
+// never change, so the stats of a trie are computed the first time it is reported and then reused.
+func (t *TxTries) Stats() (TxTriesStats, error) {
+	t.lock.RLock()
+	roots := make([]common.Hash, 0, len(t.txRoots))
+	tries := make([]storedTrie, 0, len(t.txRoots))
+	stats := TxTriesStats{Tries: make([]TrieStats, 0, len(t.txRoots))}
+	var known []bool
+	for _, root := range t.txRoots {
+		trie, exists := t.txTries[root]
+		if !exists {
+			continue
+		}
+		trieStats, ok := t.stats[root]
+		roots = append(roots, root)
+		tries = append(tries, trie)
+		stats.Tries = append(stats.Tries, trieStats)
+		known = append(known, ok)
+	}
+	t.lock.RUnlock()
+
+	for i, root := range roots {
+		if !known[i] {
+			trieStats, err := computeTrieStats(root, tries[i])
+			if err != nil {
+				return TxTriesStats{}, err
+			}
+			stats.Tries[i] = trieStats
+			t.storeStats(trieStats, tries[i])
+		}
+		stats.Nodes += stats.Tries[i].Nodes
+		stats.Bytes += stats.Tries[i].Bytes
+	}
+	return stats, nil
+}
+
+// computeTrieStats collects the hashed nodes of trie to measure them
+func computeTrieStats(root common.Hash, trie storedTrie) (TrieStats, error) {
+	nodes, err := trieNodes(trie)
+	if err != nil {
+		return TrieStats{}, err
+	}
+	count := nodes.Len()
+	// the database also counts the hashes it stores the nodes at
+	return TrieStats{Root: root, Nodes: count, Bytes: nodes.Size() - count*hashLen}, nil
+}
+
+// storeStats stores stats if trie is still stored at their root, so stats of deleted tries are never kept
+func (t *TxTries) storeStats(stats TrieStats, trie storedTrie) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.txTries[stats.Root] == trie {
+		t.stats[stats.Root] = stats
+	}
+}
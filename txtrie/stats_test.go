@@ -0,0 +1,139 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package txtrie
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// referenceTrieStats commits the trie of transactions to a database and measures what was written
+func referenceTrieStats(t *testing.T, transactions types.Transactions) TrieStats {
+	diskdb := memorydb.New()
+	triedb := trie.NewDatabase(diskdb)
+	newTrie, err := trie.New(emptyRoot, triedb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range transactions {
+		key, err := rlp.EncodeToBytes(uint(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		newTrie.Update(key, transactions.GetRlp(i))
+	}
+
+	root, err := newTrie.Commit(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := triedb.Commit(root, false, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := TrieStats{Root: root}
+	it := diskdb.NewIterator(nil, nil)
+	defer it.Release()
+	for it.Next() {
+		stats.Nodes++
+		stats.Bytes += len(it.Value())
+	}
+	return stats
+}
+
+func TestStats(t *testing.T) {
+	txTries := NewTxTries()
+	var expected []TrieStats
+	for _, vals := range []types.Transactions{GetTransactions1(), GetTransactions2(), GetTransactions3(), {}} {
+		reference := referenceTrieStats(t, vals)
+		if err := addTrie(txTries, reference.Root, vals); err != nil {
+			t.Fatal(err)
+		}
+		expected = append(expected, reference)
+	}
+
+	// the second call is served from the stored stats
+	for i := 0; i < 2; i++ {
+		stats, err := txTries.Stats()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(stats.Tries) != len(expected) {
+			t.Fatalf("unexpected number of tries, expected: %d, got: %d", len(expected), len(stats.Tries))
+		}
+
+		var nodes, bytes int
+		for j, trieStats := range stats.Tries {
+			if trieStats != expected[j] {
+				t.Fatalf("unexpected stats for trie %d, expected: %+v, got: %+v", j, expected[j], trieStats)
+			}
+			nodes += trieStats.Nodes
+			bytes += trieStats.Bytes
+		}
+		if stats.Nodes != nodes || stats.Bytes != bytes {
+			t.Fatalf("unexpected totals, expected: %d nodes %d bytes, got: %d nodes %d bytes", nodes, bytes, stats.Nodes, stats.Bytes)
+		}
+	}
+
+	if expected[3].Nodes != 0 || expected[3].Bytes != 0 {
+		t.Fatalf("empty trie should have no nodes, got: %+v", expected[3])
+	}
+}
+
+func TestStatsDeletedTrie(t *testing.T) {
+	txTries := NewTxTries()
+	vals := GetTransactions1()
+	root, err := computeEthReferenceTrieHash(vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := addTrie(txTries, root, vals); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := txTries.Stats(); err != nil {
+		t.Fatal(err)
+	}
+
+	txTries.DeleteTrie(root)
+	stats, err := txTries.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats.Tries) != 0 || stats.Nodes != 0 || stats.Bytes != 0 {
+		t.Fatalf("deleted trie still reported: %+v", stats)
+	}
+	if _, exists := txTries.stats[root]; exists {
+		t.Fatalf("stats of deleted trie %x still stored", root)
+	}
+}
+
+func TestStatsSharedRoot(t *testing.T) {
+	// an empty block has the same transaction and receipt root
+	txTries := NewTxTries()
+	if err := txTries.CreateNewTrie(emptyRoot, types.Transactions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := txTries.CreateNewReceiptTrie(emptyRoot, types.Receipts{}); err != nil {
+		t.Fatal(err)
+	}
+	txTries.DeleteTrie(emptyRoot)
+
+	stats, err := txTries.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats.Tries) != 0 {
+		t.Fatalf("deleted trie still reported: %+v", stats)
+	}
+}
+
+func TestTrieNodesInvalidTrie(t *testing.T) {
+	if _, err := trieNodes(nil); err == nil {
+		t.Fatal("expected an error for a missing trie")
+	}
+}
@@ -0,0 +1,182 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package txtrie
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/ChainSafe/chainbridge-ethereum-trie/txtrie/trienode"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Errors returned by VerifyProofStrict, wrapped in a *StrictProofError
+var (
+	ErrMissingNode       = errors.New("missing proof node")
+	ErrUnreferencedNode  = errors.New("unreferenced proof node")
+	ErrHashMismatch      = errors.New("proof node does not match its hash")
+	ErrInvalidNode       = errors.New("invalid proof node")
+	ErrNonCanonicalNode  = errors.New("non-canonical proof node")
+	ErrOversizedEmbedded = errors.New("embedded node of 32 bytes or more")
+	ErrBranchValue       = errors.New("branch node with value")
+	ErrKeyEndsMidPath    = errors.New("key ends inside a node path")
+)
+
+// StrictProofError describes why VerifyProofStrict rejected a proof. Err is one of the Err variables
+// of this package and can be matched with errors.Is.
+type StrictProofError struct {
+	Err    error
+	Node   int         // index of the offending node on the path
+	Hash   common.Hash // hash of the offending node, or of the last hashed node for embedded ones
+	Detail string
+}
+
+func (e *StrictProofError) Error() string {
+	msg := fmt.Sprintf("%v at proof node %d (hash %x)", e.Err, e.Node, e.Hash)
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	return msg
+}
+
+// Unwrap returns the Err variable describing the error
+func (e *StrictProofError) Unwrap() error {
+	return e.Err
+}
+
+// VerifyProofStrict verifies the merkle proof on path key against root like VerifyProof, and also
+// rejects proofs with nodes not on the path, non-canonical or oversized node encodings, branch nodes
+// holding values, which transaction and receipt tries never have, and keys ending inside a node path.
+// It returns whether key exists, or a *StrictProofError.
+func VerifyProofStrict(root common.Hash, key []byte, proofDb *ProofDatabase) (bool, error) {
+	value, err := verifyProofStrict(root, key, proofDb)
+	if err != nil {
+		return false, err
+	}
+	return value != nil, nil
+}
+
+func verifyProofStrict(root common.Hash, key []byte, proofDb *ProofDatabase) ([]byte, error) {
+	path := trienode.KeybytesToHex(key)
+	hash := root
+	used := 0
+
+	var n trienode.Node = trienode.HashRef(root)
+	for i := 0; ; i++ {
+		fail := func(err error, detail string) error {
+			return &StrictProofError{Err: err, Node: i, Hash: hash, Detail: detail}
+		}
+
+		if ref, ok := n.(trienode.HashRef); ok {
+			hash = common.Hash(ref)
+			buf, err := proofDb.Get(hash[:])
+			if err != nil {
+				return nil, fail(ErrMissingNode, "")
+			}
+			if n, err = decodeStrict(hash, buf, i > 0, fail); err != nil {
+				return nil, err
+			}
+			used++
+		}
+
+		switch tn := n.(type) {
+		case nil:
+			// the trie doesn't contain the key
+			return nil, checkUnreferenced(proofDb, used, fail)
+		case *trienode.Branch:
+			if tn.Value != nil {
+				return nil, fail(ErrBranchValue, "")
+			}
+			if path[0] == trienode.Terminator {
+				return nil, fail(ErrKeyEndsMidPath, "key ends at a branch node")
+			}
+			n, path = tn.Children[path[0]], path[1:]
+		case *trienode.Extension:
+			if endsInside(tn.Path, path) {
+				return nil, fail(ErrKeyEndsMidPath, fmt.Sprintf("extension path %s", nibbleString(tn.Path)))
+			}
+			if trienode.PrefixLen(tn.Path, path) != len(tn.Path) {
+				return nil, checkUnreferenced(proofDb, used, fail)
+			}
+			n, path = tn.Child, path[len(tn.Path):]
+		case *trienode.Leaf:
+			if endsInside(tn.Path, path) {
+				return nil, fail(ErrKeyEndsMidPath, fmt.Sprintf("leaf path %s", nibbleString(tn.Path)))
+			}
+			if !bytes.Equal(tn.Path, path[:len(path)-1]) {
+				return nil, checkUnreferenced(proofDb, used, fail)
+			}
+			if err := checkUnreferenced(proofDb, used, fail); err != nil {
+				return nil, err
+			}
+			return tn.Value, nil
+		}
+	}
+}
+
+// decodeStrict decodes the node with hash hash, rejecting anything go-ethereum would not have produced.
+// Nodes referenced from a parent must be at least 32 bytes long, shorter ones are embedded.
+func decodeStrict(hash common.Hash, buf []byte, referenced bool, fail func(error, string) error) (trienode.Node, error) {
+	if actual := crypto.Keccak256Hash(buf); actual != hash {
+		return nil, fail(ErrHashMismatch, fmt.Sprintf("node hashes to %x", actual))
+	}
+	if referenced && len(buf) < hashLen {
+		return nil, fail(ErrNonCanonicalNode, fmt.Sprintf("referenced node of %d bytes", len(buf)))
+	}
+	if hasOversizedEmbedded(buf) {
+		return nil, fail(ErrOversizedEmbedded, "")
+	}
+
+	n, err := trienode.Decode(buf)
+	if err != nil {
+		return nil, fail(ErrInvalidNode, err.Error())
+	}
+	enc, err := trienode.Encode(n)
+	if err != nil {
+		return nil, fail(ErrNonCanonicalNode, err.Error())
+	}
+	if !bytes.Equal(enc, buf) {
+		return nil, fail(ErrNonCanonicalNode, fmt.Sprintf("node re-encodes to %x", enc))
+	}
+	return n, nil
+}
+
+// hasOversizedEmbedded reports whether the node encoded in buf embeds a node of 32 bytes or more
+func hasOversizedEmbedded(buf []byte) bool {
+	elems, _, err := rlp.SplitList(buf)
+	if err != nil {
+		return false
+	}
+	for len(elems) > 0 {
+		kind, _, rest, err := rlp.Split(elems)
+		if err != nil {
+			return false
+		}
+		if kind == rlp.List {
+			size := len(elems) - len(rest)
+			if size >= hashLen || hasOversizedEmbedded(elems[:size]) {
+				return true
+			}
+		}
+		elems = rest
+	}
+	return false
+}
+
+// endsInside reports whether the key whose remaining nibbles are path ends strictly inside nodePath
+func endsInside(nodePath []byte, path []byte) bool {
+	rest := path[:len(path)-1] // drop the terminator
+	return len(rest) < len(nodePath) && bytes.Equal(rest, nodePath[:len(rest)])
+}
+
+// checkUnreferenced fails if proofDb holds more than the used nodes of the path
+func checkUnreferenced(proofDb *ProofDatabase, used int, fail func(error, string) error) error {
+	if count := proofDb.Len(); count != used {
+		return fail(ErrUnreferencedNode, fmt.Sprintf("%d nodes in proof, %d on path", count, used))
+	}
+	return nil
+}
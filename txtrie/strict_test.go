@@ -0,0 +1,184 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package txtrie
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/ChainSafe/chainbridge-ethereum-trie/txtrie/trienode"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// newHandmadeProof stores the encoded nodes by hash and returns the hash of the first one as root
+func newHandmadeProof(t *testing.T, nodes ...[]byte) (common.Hash, *ProofDatabase) {
+	proofDb := NewProofDatabase()
+	for _, enc := range nodes {
+		if err := proofDb.Put(crypto.Keccak256(enc), enc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return crypto.Keccak256Hash(nodes[0]), proofDb
+}
+
+func mustEncodeNode(t *testing.T, n trienode.Node) []byte {
+	enc, err := trienode.Encode(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return enc
+}
+
+func TestVerifyProofStrict(t *testing.T) {
+	txTries, proofDb, key := retrieveTestProof(t, 1)
+	root := txTries.txRoots[0]
+
+	exists, err := VerifyProofStrict(root, key, proofDb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !exists {
+		t.Fatalf("not able to verify proof in strict mode")
+	}
+
+	missingKey, err := rlp.EncodeToBytes(uint(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	absenceProof, err := txTries.RetrieveProof(root, missingKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exists, err = VerifyProofStrict(root, missingKey, absenceProof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exists {
+		t.Fatalf("absence proof verified as existing")
+	}
+
+	// merging both proofs leaves nodes off the path of either key
+	entries, err := absenceProof.sortedEntries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if err := proofDb.Put(entry.Key, entry.Value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := VerifyProofStrict(root, key, proofDb); !errors.Is(err, ErrUnreferencedNode) {
+		t.Fatalf("expected unreferenced node error, got: %v", err)
+	}
+	if exists, err := VerifyProof(root, key, proofDb); err != nil || !exists {
+		t.Fatalf("merged proof should verify in lenient mode: %v", err)
+	}
+
+	if _, err := VerifyProofStrict(root, key, NewProofDatabase()); !errors.Is(err, ErrMissingNode) {
+		t.Fatalf("expected missing node error, got: %v", err)
+	}
+}
+
+func TestVerifyProofStrictMalformed(t *testing.T) {
+	key := []byte{0x12}
+	value := bytes.Repeat([]byte{0xaa}, 40)
+
+	leaf := mustEncodeNode(t, &trienode.Leaf{Path: []byte{1, 2}, Value: value})
+	shortLeaf := mustEncodeNode(t, &trienode.Leaf{Path: []byte{2}, Value: []byte{1}})
+
+	withValue := &trienode.Branch{Value: []byte{1}}
+	withValue.Children[1] = trienode.HashRef(crypto.Keccak256Hash(leaf))
+
+	// a branch referencing a node short enough to be embedded by hash
+	hashedShort := new(trienode.Branch)
+	hashedShort.Children[1] = trienode.HashRef(crypto.Keccak256Hash(shortLeaf))
+	hashedShort.Children[2] = trienode.HashRef(crypto.Keccak256Hash(leaf))
+
+	// a branch embedding a node of more than 32 bytes
+	elems := make([]interface{}, 17)
+	for i := range elems {
+		elems[i] = rlp.RawValue(rlp.EmptyString)
+	}
+	elems[1] = rlp.RawValue(mustEncodeNode(t, &trienode.Leaf{Path: []byte{2}, Value: value}))
+	elems[2] = rlp.RawValue(shortLeaf)
+	oversized, err := rlp.EncodeToBytes(elems)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		nodes  [][]byte
+		err    error
+		detail bool // whether the error carries its cause
+	}{
+		{"branch value", [][]byte{mustEncodeNode(t, withValue), leaf}, ErrBranchValue, false},
+		{"hashed short node", [][]byte{mustEncodeNode(t, hashedShort), shortLeaf, leaf}, ErrNonCanonicalNode, true},
+		{"oversized embedded node", [][]byte{oversized}, ErrOversizedEmbedded, false},
+		{"key ends in leaf", [][]byte{mustEncodeNode(t, &trienode.Leaf{Path: []byte{1, 2, 3, 4}, Value: value})}, ErrKeyEndsMidPath, true},
+		{"invalid node", [][]byte{append([]byte{0xc3, 0x01, 0x02, 0x03}, bytes.Repeat([]byte{0}, 32)...)}, ErrInvalidNode, true},
+	}
+	for _, test := range tests {
+		root, proofDb := newHandmadeProof(t, test.nodes...)
+		_, err := VerifyProofStrict(root, key, proofDb)
+		if !errors.Is(err, test.err) {
+			t.Fatalf("%s: expected: %v, got: %v", test.name, test.err, err)
+		}
+		var strictErr *StrictProofError
+		if !errors.As(err, &strictErr) {
+			t.Fatalf("%s: error is not a StrictProofError: %T", test.name, err)
+		}
+		if test.detail && strictErr.Detail == "" {
+			t.Fatalf("%s: cause of the error is missing: %v", test.name, err)
+		}
+	}
+
+	root, proofDb := newHandmadeProof(t, leaf)
+	if err := proofDb.Put(root[:], shortLeaf); err != nil {
+		t.Fatal(err)
+	}
+	_, err = VerifyProofStrict(root, key, proofDb)
+	if !errors.Is(err, ErrHashMismatch) {
+		t.Fatalf("expected hash mismatch, got: %v", err)
+	}
+	if strictErr := err.(*StrictProofError); strictErr.Detail == "" {
+		t.Fatalf("cause of the hash mismatch is missing: %v", err)
+	}
+}
+
+func TestVerifyProofStrictMatchesVerifyProof(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, count := range []int{1, 2, 16, 17, 129, 300} {
+		for _, maxValueSize := range []int{8, 200} {
+			txTries, root := newRandomTxTries(t, rng, count, maxValueSize)
+
+			for i := 0; i < count+5; i++ {
+				key, err := rlp.EncodeToBytes(uint(i))
+				if err != nil {
+					t.Fatal(err)
+				}
+				proofDb, err := txTries.RetrieveProof(root, key)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				expected, err := verifyProof(root, key, proofDb)
+				if err != nil {
+					t.Fatal(err)
+				}
+				value, err := verifyProofStrict(root, key, proofDb)
+				if err != nil {
+					t.Fatalf("strict verification failed, root: %x, key: %x: %v", root, key, err)
+				}
+				if !bytes.Equal(value, expected) {
+					t.Fatalf("verified values differ, root: %x, key: %x, expected: %x, got: %x", root, key, expected, value)
+				}
+			}
+		}
+	}
+}
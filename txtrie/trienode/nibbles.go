@@ -0,0 +1,116 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package trienode
+
+// Terminator is the nibble marking the end of a key in hex encoding
+const Terminator = 16
+
+// KeybytesToHex converts a key to hex encoding, one nibble per byte followed by the terminator
+func KeybytesToHex(key []byte) []byte {
+	l := len(key)*2 + 1
+	var nibbles = make([]byte, l)
+	for i, b := range key {
+		nibbles[i*2] = b / 16
+		nibbles[i*2+1] = b % 16
+	}
+	nibbles[l-1] = Terminator
+	return nibbles
+}
+
+// HexToKeybytes converts hex encoded nibbles back to a key, the terminator is optional
+// and the number of remaining nibbles must be even
+func HexToKeybytes(hex []byte) ([]byte, bool) {
+	if HasTerm(hex) {
+		hex = hex[:len(hex)-1]
+	}
+	if len(hex)&1 != 0 || !validNibbles(hex) {
+		return nil, false
+	}
+	key := make([]byte, len(hex)/2)
+	decodeNibbles(hex, key)
+	return key, true
+}
+
+// CompactToHex converts a hex-prefix (compact) encoded path to hex encoding. The terminator
+// is appended if the path belongs to a leaf.
+func CompactToHex(compact []byte) []byte {
+	if len(compact) == 0 {
+		return compact
+	}
+	base := KeybytesToHex(compact)
+	// delete terminator flag
+	if base[0] < 2 {
+		base = base[:len(base)-1]
+	}
+	// apply odd flag
+	chop := 2 - base[0]&1
+	return base[chop:]
+}
+
+// HexToCompact converts hex encoded nibbles to hex-prefix (compact) encoding, a trailing
+// terminator sets the leaf flag
+func HexToCompact(hex []byte) []byte {
+	terminator := byte(0)
+	if HasTerm(hex) {
+		terminator = 1
+		hex = hex[:len(hex)-1]
+	}
+	buf := make([]byte, len(hex)/2+1)
+	buf[0] = terminator << 5 // the flag byte
+	if len(hex)&1 == 1 {
+		buf[0] |= 1 << 4 // odd flag
+		buf[0] |= hex[0] // first nibble is contained in the first byte
+		hex = hex[1:]
+	}
+	decodeNibbles(hex, buf[1:])
+	return buf
+}
+
+// ValidCompact reports whether compact is a well formed hex-prefix encoding
+func ValidCompact(compact []byte) bool {
+	if len(compact) == 0 {
+		return false
+	}
+	flag := compact[0] >> 4
+	if flag > 3 {
+		return false
+	}
+	// even paths pad the flag byte with a zero nibble
+	return flag&1 == 1 || compact[0]&0x0f == 0
+}
+
+// HasTerm reports whether hex ends with the terminator
+func HasTerm(hex []byte) bool {
+	return len(hex) > 0 && hex[len(hex)-1] == Terminator
+}
+
+// PrefixLen returns the length of the common prefix of a and b
+func PrefixLen(a, b []byte) int {
+	var i, length = 0, len(a)
+	if len(b) < length {
+		length = len(b)
+	}
+	for ; i < length; i++ {
+		if a[i] != b[i] {
+			break
+		}
+	}
+	return i
+}
+
+func decodeNibbles(nibbles []byte, bytes []byte) {
+	for bi, ni := 0, 0; ni < len(nibbles); bi, ni = bi+1, ni+2 {
+		bytes[bi] = nibbles[ni]<<4 | nibbles[ni+1]
+	}
+}
+
+// validNibbles reports whether every element of nibbles is a nibble, the terminator excluded
+func validNibbles(nibbles []byte) bool {
+	for _, n := range nibbles {
+		if n > 15 {
+			return false
+		}
+	}
+	return true
+}
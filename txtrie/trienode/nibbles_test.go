@@ -0,0 +1,66 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package trienode
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestHexCompact(t *testing.T) {
+	tests := []struct{ hex, compact []byte }{
+		// empty keys, with and without terminator
+		{hex: []byte{}, compact: []byte{0x00}},
+		{hex: []byte{16}, compact: []byte{0x20}},
+		// odd length, no terminator
+		{hex: []byte{1, 2, 3, 4, 5}, compact: []byte{0x11, 0x23, 0x45}},
+		// even length, no terminator
+		{hex: []byte{0, 1, 2, 3, 4, 5}, compact: []byte{0x00, 0x01, 0x23, 0x45}},
+		// odd length, terminator
+		{hex: []byte{15, 1, 12, 11, 8, 16}, compact: []byte{0x3f, 0x1c, 0xb8}},
+		// even length, terminator
+		{hex: []byte{0, 15, 1, 12, 11, 8, 16}, compact: []byte{0x20, 0x0f, 0x1c, 0xb8}},
+	}
+	for _, test := range tests {
+		if c := HexToCompact(test.hex); !bytes.Equal(c, test.compact) {
+			t.Fatalf("hexToCompact(%x) -> %x, expected: %x", test.hex, c, test.compact)
+		}
+		if !ValidCompact(test.compact) {
+			t.Fatalf("valid compact path %x rejected", test.compact)
+		}
+		if h := CompactToHex(test.compact); !bytes.Equal(h, test.hex) {
+			t.Fatalf("compactToHex(%x) -> %x, expected: %x", test.compact, h, test.hex)
+		}
+	}
+
+	for _, compact := range [][]byte{{}, {0x01}, {0x40}, {0x21, 0x23}} {
+		if ValidCompact(compact) {
+			t.Fatalf("invalid compact path %x accepted", compact)
+		}
+	}
+}
+
+func TestHexKeybytes(t *testing.T) {
+	tests := []struct{ key, hex []byte }{
+		{key: []byte{}, hex: []byte{16}},
+		{key: []byte{0x12, 0x34, 0x56}, hex: []byte{1, 2, 3, 4, 5, 6, 16}},
+		{key: []byte{0x12, 0x34, 0x5}, hex: []byte{1, 2, 3, 4, 0, 5, 16}},
+	}
+	for _, test := range tests {
+		if h := KeybytesToHex(test.key); !bytes.Equal(h, test.hex) {
+			t.Fatalf("keybytesToHex(%x) -> %x, expected: %x", test.key, h, test.hex)
+		}
+		k, ok := HexToKeybytes(test.hex)
+		if !ok || !bytes.Equal(k, test.key) {
+			t.Fatalf("hexToKeybytes(%x) -> %x, expected: %x", test.hex, k, test.key)
+		}
+	}
+
+	if _, ok := HexToKeybytes([]byte{1, 2, 3, 16}); ok {
+		t.Fatalf("odd number of nibbles accepted")
+	}
+	if PrefixLen([]byte{1, 2, 3}, []byte{1, 2, 4, 5}) != 2 {
+		t.Fatalf("unexpected common prefix length")
+	}
+}
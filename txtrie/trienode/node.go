@@ -0,0 +1,209 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+// Package trienode decodes and encodes the nodes of Ethereum Merkle Patricia tries
+package trienode
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Node is a trie node: *Branch, *Extension, *Leaf, or HashRef when referenced from a parent
+type Node interface {
+	isNode()
+}
+
+type (
+	// Branch is a node with a child per nibble and an optional value
+	Branch struct {
+		Children [16]Node // nil, HashRef or an embedded node
+		Value    []byte   // nil if no key ends at the branch
+	}
+	// Extension is a node sharing a path between the keys of its child
+	Extension struct {
+		Path  []byte // nibbles, without terminator
+		Child Node   // HashRef or an embedded node
+	}
+	// Leaf is a node holding the value of the key ending with Path
+	Leaf struct {
+		Path  []byte // nibbles, without terminator
+		Value []byte
+	}
+	// HashRef references a child node by the keccak256 hash of its encoding
+	HashRef common.Hash
+)
+
+func (*Branch) isNode()    {}
+func (*Extension) isNode() {}
+func (*Leaf) isNode()      {}
+func (HashRef) isNode()    {}
+
+const hashLen = len(common.Hash{})
+
+// Decode parses the RLP encoding of a trie node
+func Decode(buf []byte) (Node, error) {
+	elems, rest, err := rlp.SplitList(buf)
+	if err != nil {
+		return nil, fmt.Errorf("decode error: %v", err)
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("trailing bytes after node")
+	}
+	switch c, _ := rlp.CountValues(elems); c {
+	case 2:
+		return decodeShort(elems)
+	case 17:
+		return decodeBranch(elems)
+	default:
+		return nil, fmt.Errorf("invalid number of list elements: %v", c)
+	}
+}
+
+func decodeShort(elems []byte) (Node, error) {
+	compact, rest, err := rlp.SplitString(elems)
+	if err != nil {
+		return nil, err
+	}
+	if !ValidCompact(compact) {
+		return nil, fmt.Errorf("invalid compact path %x", compact)
+	}
+	path := CompactToHex(compact)
+
+	if HasTerm(path) {
+		val, _, err := rlp.SplitString(rest)
+		if err != nil {
+			return nil, fmt.Errorf("invalid leaf value: %v", err)
+		}
+		return &Leaf{Path: path[:len(path)-1], Value: common.CopyBytes(val)}, nil
+	}
+
+	child, _, err := decodeRef(rest)
+	if err != nil {
+		return nil, fmt.Errorf("invalid extension child: %v", err)
+	}
+	if child == nil {
+		return nil, errors.New("extension without child")
+	}
+	return &Extension{Path: path, Child: child}, nil
+}
+
+func decodeBranch(elems []byte) (*Branch, error) {
+	n := new(Branch)
+	for i := range n.Children {
+		child, rest, err := decodeRef(elems)
+		if err != nil {
+			return nil, fmt.Errorf("invalid branch child %d: %v", i, err)
+		}
+		n.Children[i], elems = child, rest
+	}
+	val, _, err := rlp.SplitString(elems)
+	if err != nil {
+		return nil, fmt.Errorf("invalid branch value: %v", err)
+	}
+	if len(val) > 0 {
+		n.Value = common.CopyBytes(val)
+	}
+	return n, nil
+}
+
+func decodeRef(buf []byte) (Node, []byte, error) {
+	kind, val, rest, err := rlp.Split(buf)
+	if err != nil {
+		return nil, buf, err
+	}
+	switch {
+	case kind == rlp.List:
+		// embedded node, its encoding must be smaller than a hash
+		if size := len(buf) - len(rest); size >= hashLen {
+			return nil, buf, fmt.Errorf("oversized embedded node (size is %d bytes, want size < %d)", size, hashLen)
+		}
+		n, err := Decode(buf[:len(buf)-len(rest)])
+		return n, rest, err
+	case kind == rlp.String && len(val) == 0:
+		return nil, rest, nil
+	case kind == rlp.String && len(val) == hashLen:
+		return HashRef(common.BytesToHash(val)), rest, nil
+	default:
+		return nil, buf, fmt.Errorf("invalid RLP string size %d (want 0 or 32)", len(val))
+	}
+}
+
+// Encode returns the canonical RLP encoding of n, embedding children given as nodes
+func Encode(n Node) ([]byte, error) {
+	switch n := n.(type) {
+	case *Branch:
+		elems := make([]interface{}, 17)
+		for i, child := range n.Children {
+			ref, err := encodeRef(child)
+			if err != nil {
+				return nil, fmt.Errorf("invalid branch child %d: %v", i, err)
+			}
+			elems[i] = ref
+		}
+		elems[16] = n.Value
+		if n.Value == nil {
+			elems[16] = []byte{}
+		}
+		return rlp.EncodeToBytes(elems)
+	case *Extension:
+		if !validNibbles(n.Path) {
+			return nil, errors.New("invalid extension path")
+		}
+		if n.Child == nil {
+			return nil, errors.New("extension without child")
+		}
+		ref, err := encodeRef(n.Child)
+		if err != nil {
+			return nil, fmt.Errorf("invalid extension child: %v", err)
+		}
+		return rlp.EncodeToBytes([]interface{}{HexToCompact(n.Path), ref})
+	case *Leaf:
+		if !validNibbles(n.Path) {
+			return nil, errors.New("invalid leaf path")
+		}
+		path := append(append([]byte{}, n.Path...), Terminator)
+		value := n.Value
+		if value == nil {
+			value = []byte{}
+		}
+		return rlp.EncodeToBytes([]interface{}{HexToCompact(path), value})
+	default:
+		return nil, fmt.Errorf("%T: cannot encode node", n)
+	}
+}
+
+// encodeRef returns the encoding of n as a child reference
+func encodeRef(n Node) (rlp.RawValue, error) {
+	switch n := n.(type) {
+	case nil:
+		return rlp.EmptyString, nil
+	case HashRef:
+		return rlp.EncodeToBytes(n[:])
+	default:
+		enc, err := Encode(n)
+		if err != nil {
+			return nil, err
+		}
+		if len(enc) >= hashLen {
+			return nil, fmt.Errorf("embedded node of %d bytes must be referenced by hash", len(enc))
+		}
+		return enc, nil
+	}
+}
+
+// Hash returns the keccak256 hash of the encoding of n, which is how parents reference it
+func Hash(n Node) (common.Hash, error) {
+	if ref, ok := n.(HashRef); ok {
+		return common.Hash(ref), nil
+	}
+	enc, err := Encode(n)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(enc), nil
+}
@@ -0,0 +1,202 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package trienode
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	ethtrie "github.com/ethereum/go-ethereum/trie"
+)
+
+// newRandomTrie builds a trie with keys of varying length, so some keys are prefixes
+// of others and end at branch nodes, and values small enough to embed nodes
+func newRandomTrie(t *testing.T, rng *rand.Rand, count int) (*ethtrie.Trie, map[string][]byte) {
+	trie, err := ethtrie.New(common.Hash{}, ethtrie.NewDatabase(memorydb.New()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := make(map[string][]byte)
+	for i := 0; i < count; i++ {
+		key := make([]byte, 1+rng.Intn(4))
+		rng.Read(key)
+		value := make([]byte, 1+rng.Intn(40))
+		rng.Read(value)
+
+		trie.Update(key, value)
+		entries[string(key)] = value
+	}
+	return trie, entries
+}
+
+// lookup follows key from root through the decoded nodes in db
+func lookup(t *testing.T, db *memorydb.Database, root common.Hash, key []byte) []byte {
+	path := KeybytesToHex(key)
+	var n Node = HashRef(root)
+	for {
+		switch tn := n.(type) {
+		case HashRef:
+			buf, err := db.Get(tn[:])
+			if err != nil {
+				t.Fatalf("node %x missing: %v", tn[:], err)
+			}
+			if n, err = Decode(buf); err != nil {
+				t.Fatal(err)
+			}
+		case *Branch:
+			if path[0] == Terminator {
+				return tn.Value
+			}
+			n, path = tn.Children[path[0]], path[1:]
+		case *Extension:
+			if PrefixLen(tn.Path, path) != len(tn.Path) {
+				return nil
+			}
+			n, path = tn.Child, path[len(tn.Path):]
+		case *Leaf:
+			if !bytes.Equal(append(tn.Path, Terminator), path) {
+				return nil
+			}
+			return tn.Value
+		case nil:
+			return nil
+		}
+	}
+}
+
+func TestNodeRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	kinds := make(map[string]int)
+	var count func(n Node)
+	count = func(n Node) {
+		switch n := n.(type) {
+		case *Branch:
+			kinds["branch"]++
+			if n.Value != nil {
+				kinds["branch value"]++
+			}
+			for _, child := range n.Children {
+				if child != nil {
+					if _, ok := child.(HashRef); !ok {
+						kinds["embedded"]++
+					}
+				}
+				count(child)
+			}
+		case *Extension:
+			kinds["extension"]++
+			count(n.Child)
+		case *Leaf:
+			kinds["leaf"]++
+		}
+	}
+
+	for i := 0; i < 20; i++ {
+		trie, entries := newRandomTrie(t, rng, 1+rng.Intn(300))
+		root := trie.Hash()
+
+		proofDb := memorydb.New()
+		for key, value := range entries {
+			if err := trie.Prove([]byte(key), 0, proofDb); err != nil {
+				t.Fatal(err)
+			}
+			if got := lookup(t, proofDb, root, []byte(key)); !bytes.Equal(got, value) {
+				t.Fatalf("unexpected value for key %x, expected: %x, got: %x", key, value, got)
+			}
+		}
+
+		it := proofDb.NewIterator(nil, nil)
+		for it.Next() {
+			n, err := Decode(it.Value())
+			if err != nil {
+				t.Fatal(err)
+			}
+			count(n)
+
+			enc, err := Encode(n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(enc, it.Value()) {
+				t.Fatalf("re-encoded node differs, expected: %x, got: %x", it.Value(), enc)
+			}
+			hash, err := Hash(n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(hash[:], it.Key()) {
+				t.Fatalf("unexpected node hash, expected: %x, got: %x", it.Key(), hash)
+			}
+		}
+		it.Release()
+	}
+
+	for _, kind := range []string{"branch", "branch value", "extension", "leaf", "embedded"} {
+		if kinds[kind] == 0 {
+			t.Fatalf("no %s nodes were decoded", kind)
+		}
+	}
+}
+
+func TestEncodeBuiltTrie(t *testing.T) {
+	leaf := &Leaf{Path: []byte{1, 2, 3}, Value: []byte("value")}
+	big := &Leaf{Path: []byte{4}, Value: bytes.Repeat([]byte{1}, 40)}
+	bigHash, err := Hash(big)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	branch := new(Branch)
+	branch.Children[0] = leaf
+	branch.Children[5] = HashRef(bigHash)
+	branchHash, err := Hash(branch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := &Extension{Path: []byte{6, 1}, Child: HashRef(branchHash)}
+
+	enc, err := Encode(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// build the same trie with go-ethereum
+	trie, err := ethtrie.New(common.Hash{}, ethtrie.NewDatabase(memorydb.New()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	trie.Update([]byte{0x61, 0x01, 0x23}, leaf.Value)
+	trie.Update([]byte{0x61, 0x54}, big.Value)
+
+	if expected := trie.Hash(); crypto.Keccak256Hash(enc) != expected {
+		t.Fatalf("unexpected root, expected: %x, got: %x", expected, crypto.Keccak256Hash(enc))
+	}
+
+	branch.Children[5] = big
+	if _, err := Encode(branch); err == nil {
+		t.Fatalf("expected oversized embedded node to fail")
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	tests := [][]byte{
+		{},
+		{0x80},
+		{0xc2, 0x80, 0x80},                  // invalid compact path
+		{0xc3, 0x20, 0x80, 0x00},            // trailing element
+		{0xc2, 0x00, 0x80},                  // extension without child
+		append([]byte{0xc2, 0x20, 0x80}, 0), // trailing bytes
+	}
+	for _, buf := range tests {
+		if n, err := Decode(buf); err == nil {
+			t.Fatalf("invalid node %x decoded to %+v", buf, n)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+// Package txgen deterministically generates signed transactions, receipts and blocks for tests
+// and benchmarks. The supported go-ethereum version only has legacy transactions, so transaction
+// kinds are told apart by what they do rather than by their envelope type.
+package txgen
+
+import (
+	"crypto/ecdsa"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/rand"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	ethtrie "github.com/ethereum/go-ethereum/trie"
+)
+
+// TxKind identifies what a generated transaction does
+type TxKind uint8
+
+// Transaction kinds produced by Generator
+const (
+	Transfer TxKind = iota
+	ContractCreation
+	ContractCall
+)
+
+// AllKinds lists every transaction kind
+var AllKinds = []TxKind{Transfer, ContractCreation, ContractCall}
+
+func (k TxKind) String() string {
+	switch k {
+	case Transfer:
+		return "transfer"
+	case ContractCreation:
+		return "contract creation"
+	case ContractCall:
+		return "contract call"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(k))
+	}
+}
+
+const (
+	// DefaultAccounts is the number of signing accounts used unless configured otherwise
+	DefaultAccounts = 4
+	// DefaultGasLimit is the minimum gas limit of generated blocks
+	DefaultGasLimit = 12500000
+	// blockTime is the number of seconds between generated blocks
+	blockTime = 13
+)
+
+// DefaultChainID is the chain id transactions are signed for unless configured otherwise
+var DefaultChainID = big.NewInt(1337)
+
+// Config configures a Generator, zero values select the defaults
+type Config struct {
+	Seed     int64
+	ChainID  *big.Int
+	Accounts int
+}
+
+// Generator produces a deterministic chain of blocks, the same Config always yields the same blocks
+type Generator struct {
+	rng    *rand.Rand
+	signer types.Signer
+	keys   []*ecdsa.PrivateKey
+	nonces []uint64
+	parent *types.Header
+}
+
+// NewGenerator creates a Generator whose chain starts at a deterministic genesis header
+func NewGenerator(cfg Config) (*Generator, error) {
+	if cfg.ChainID == nil {
+		cfg.ChainID = DefaultChainID
+	}
+	if cfg.Accounts == 0 {
+		cfg.Accounts = DefaultAccounts
+	}
+	if cfg.Accounts < 0 {
+		return nil, errors.New("number of accounts cannot be negative")
+	}
+
+	g := &Generator{
+		rng:    rand.New(rand.NewSource(cfg.Seed)),
+		signer: types.NewEIP155Signer(cfg.ChainID),
+		nonces: make([]uint64, cfg.Accounts),
+	}
+
+	for i := 0; len(g.keys) < cfg.Accounts; i++ {
+		var seed [16]byte
+		binary.BigEndian.PutUint64(seed[:8], uint64(cfg.Seed))
+		binary.BigEndian.PutUint64(seed[8:], uint64(i))
+		// the hash may fall outside of the curve order, skip it then
+		key, err := crypto.ToECDSA(crypto.Keccak256(seed[:]))
+		if err != nil {
+			continue
+		}
+		g.keys = append(g.keys, key)
+	}
+
+	g.parent = &types.Header{
+		ParentHash:  common.Hash{},
+		UncleHash:   types.EmptyUncleHash,
+		Root:        crypto.Keccak256Hash(big.NewInt(cfg.Seed).Bytes()),
+		TxHash:      types.EmptyRootHash,
+		ReceiptHash: types.EmptyRootHash,
+		Difficulty:  big.NewInt(1),
+		Number:      big.NewInt(0),
+		GasLimit:    DefaultGasLimit,
+	}
+	return g, nil
+}
+
+// Keys returns the private keys signing the generated transactions
+func (g *Generator) Keys() []*ecdsa.PrivateKey {
+	return g.keys
+}
+
+// Addresses returns the addresses sending the generated transactions
+func (g *Generator) Addresses() []common.Address {
+	addresses := make([]common.Address, len(g.keys))
+	for i, key := range g.keys {
+		addresses[i] = crypto.PubkeyToAddress(key.PublicKey)
+	}
+	return addresses
+}
+
+// Signer returns the signer used for the generated transactions
+func (g *Generator) Signer() types.Signer {
+	return g.signer
+}
+
+// Head returns the header of the last generated block, or the genesis header
+func (g *Generator) Head() *types.Header {
+	return types.CopyHeader(g.parent)
+}
+
+// Transaction signs a new transaction of kind from a random account
+func (g *Generator) Transaction(kind TxKind) (*types.Transaction, error) {
+	account := g.rng.Intn(len(g.keys))
+	nonce := g.nonces[account]
+	gasPrice := new(big.Int).Mul(big.NewInt(int64(1+g.rng.Intn(100))), big.NewInt(1e9))
+
+	var tx *types.Transaction
+	switch kind {
+	case Transfer:
+		value := new(big.Int).Mul(big.NewInt(int64(1+g.rng.Intn(1000))), big.NewInt(1e15))
+		tx = types.NewTransaction(nonce, g.address(), value, 21000, gasPrice, nil)
+	case ContractCreation:
+		tx = types.NewContractCreation(nonce, new(big.Int), 100000+uint64(g.rng.Intn(400000)), gasPrice, g.bytes(10+g.rng.Intn(500)))
+	case ContractCall:
+		// a selector followed by 32 byte words
+		data := g.bytes(4 + 32*g.rng.Intn(8))
+		tx = types.NewTransaction(nonce, g.address(), new(big.Int), 30000+uint64(g.rng.Intn(200000)), gasPrice, data)
+	default:
+		return nil, fmt.Errorf("unknown transaction kind %d", kind)
+	}
+
+	signedTx, err := types.SignTx(tx, g.signer, g.keys[account])
+	if err != nil {
+		return nil, err
+	}
+	g.nonces[account]++
+	return signedTx, nil
+}
+
+// Block generates the next block of the chain with n transactions of each of kinds, or of every
+// kind if none are given, interleaved by kind. It returns the block with its receipts.
+func (g *Generator) Block(n int, kinds ...TxKind) (*types.Block, types.Receipts, error) {
+	if n < 0 {
+		return nil, nil, errors.New("number of transactions cannot be negative")
+	}
+	if len(kinds) == 0 {
+		kinds = AllKinds
+	}
+
+	txs := make(types.Transactions, 0, n*len(kinds))
+	for i := 0; i < n; i++ {
+		for _, kind := range kinds {
+			tx, err := g.Transaction(kind)
+			if err != nil {
+				return nil, nil, err
+			}
+			txs = append(txs, tx)
+		}
+	}
+
+	number := new(big.Int).Add(g.parent.Number, common.Big1)
+	receipts, gasUsed, err := g.receipts(txs, number)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	header := &types.Header{
+		ParentHash: g.parent.Hash(),
+		Coinbase:   g.address(),
+		Root:       crypto.Keccak256Hash(g.parent.Root[:]),
+		Difficulty: big.NewInt(1),
+		Number:     number,
+		GasLimit:   DefaultGasLimit,
+		GasUsed:    gasUsed,
+		Time:       g.parent.Time + blockTime,
+	}
+	if gasUsed > header.GasLimit {
+		header.GasLimit = gasUsed
+	}
+	block := types.NewBlock(header, txs, nil, receipts, new(ethtrie.Trie))
+
+	for _, receipt := range receipts {
+		receipt.BlockHash = block.Hash()
+		for _, log := range receipt.Logs {
+			log.BlockHash = block.Hash()
+		}
+	}
+
+	g.parent = block.Header()
+	return block, receipts, nil
+}
+
+// receipts creates successful receipts for txs in block number, every contract call emits one log
+func (g *Generator) receipts(txs types.Transactions, number *big.Int) (types.Receipts, uint64, error) {
+	receipts := make(types.Receipts, len(txs))
+	var cumulativeGasUsed uint64
+	var logIndex uint
+	for i, tx := range txs {
+		// the whole gas limit is used, which keeps the amounts deterministic
+		cumulativeGasUsed += tx.Gas()
+
+		receipt := types.NewReceipt(nil, false, cumulativeGasUsed)
+		receipt.TxHash = tx.Hash()
+		receipt.GasUsed = tx.Gas()
+		receipt.BlockNumber = number
+		receipt.TransactionIndex = uint(i)
+
+		if tx.To() == nil {
+			from, err := types.Sender(g.signer, tx)
+			if err != nil {
+				return nil, 0, err
+			}
+			receipt.ContractAddress = crypto.CreateAddress(from, tx.Nonce())
+		} else if len(tx.Data()) > 0 {
+			receipt.Logs = []*types.Log{{
+				Address:     *tx.To(),
+				Topics:      []common.Hash{crypto.Keccak256Hash(tx.Data()[:4])},
+				Data:        common.CopyBytes(tx.Data()[4:]),
+				BlockNumber: number.Uint64(),
+				TxHash:      tx.Hash(),
+				TxIndex:     uint(i),
+				Index:       logIndex,
+			}}
+			logIndex++
+		}
+		receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+		receipts[i] = receipt
+	}
+	return receipts, cumulativeGasUsed, nil
+}
+
+func (g *Generator) address() common.Address {
+	return common.BytesToAddress(g.bytes(common.AddressLength))
+}
+
+func (g *Generator) bytes(n int) []byte {
+	b := make([]byte, n)
+	g.rng.Read(b)
+	return b
+}
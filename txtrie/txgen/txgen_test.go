@@ -0,0 +1,100 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package txgen
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	ethtrie "github.com/ethereum/go-ethereum/trie"
+)
+
+func TestGeneratorDeterministic(t *testing.T) {
+	var hashes [2][]string
+	for run := range hashes {
+		g, err := NewGenerator(Config{Seed: 7})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 3; i++ {
+			block, _, err := g.Block(5)
+			if err != nil {
+				t.Fatal(err)
+			}
+			hashes[run] = append(hashes[run], block.Hash().Hex())
+		}
+	}
+
+	for i := range hashes[0] {
+		if hashes[0][i] != hashes[1][i] {
+			t.Fatalf("block %d differs between runs, expected: %s, got: %s", i, hashes[0][i], hashes[1][i])
+		}
+	}
+
+	other, err := NewGenerator(Config{Seed: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _, err := other.Block(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if block.Hash().Hex() == hashes[0][0] {
+		t.Fatalf("different seeds generated the same block")
+	}
+}
+
+func TestGeneratorBlock(t *testing.T) {
+	g, err := NewGenerator(Config{Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	genesis := g.Head()
+
+	block, receipts, err := g.Block(4, Transfer, ContractCall)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if block.NumberU64() != 1 || block.ParentHash() != genesis.Hash() {
+		t.Fatalf("block does not extend genesis, number: %d, parent: %x", block.NumberU64(), block.ParentHash())
+	}
+	if len(block.Transactions()) != 8 || len(receipts) != 8 {
+		t.Fatalf("unexpected transaction count, expected: %d, got: %d", 8, len(block.Transactions()))
+	}
+	if root := types.DeriveSha(block.Transactions(), new(ethtrie.Trie)); root != block.TxHash() {
+		t.Fatalf("unexpected transaction root, expected: %x, got: %x", root, block.TxHash())
+	}
+	if root := types.DeriveSha(receipts, new(ethtrie.Trie)); root != block.ReceiptHash() {
+		t.Fatalf("unexpected receipt root, expected: %x, got: %x", root, block.ReceiptHash())
+	}
+	if block.GasUsed() != receipts[len(receipts)-1].CumulativeGasUsed {
+		t.Fatalf("unexpected gas used, expected: %d, got: %d", receipts[len(receipts)-1].CumulativeGasUsed, block.GasUsed())
+	}
+
+	senders := make(map[string]bool)
+	for _, address := range g.Addresses() {
+		senders[address.Hex()] = true
+	}
+	for i, tx := range block.Transactions() {
+		from, err := types.Sender(g.Signer(), tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !senders[from.Hex()] {
+			t.Fatalf("transaction %d signed by unknown account %x", i, from)
+		}
+		if i%2 == 1 && len(receipts[i].Logs) != 1 {
+			t.Fatalf("contract call %d did not emit a log", i)
+		}
+	}
+
+	next, _, err := g.Block(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.ParentHash() != block.Hash() || next.TxHash() != types.EmptyRootHash {
+		t.Fatalf("unexpected empty block, parent: %x, tx root: %x", next.ParentHash(), next.TxHash())
+	}
+}
@@ -5,7 +5,8 @@ package txtrie
 
 import (
 	"errors"
-	"fmt"
+	"sync"
+
 	"github.com/ethereum/go-ethereum/ethdb"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -17,11 +18,17 @@ import (
 // TxTries stores all the instances of tries we have on disk
 type TxTries struct {
 	// TODO: the memory allocated for these is hard to get back, look for better way to have a queue
-	txTries map[common.Hash]*ethtrie.Trie
+	txTries map[common.Hash]storedTrie
 	txRoots []common.Hash // needed to track insertion order
+	cache   *proofCache   // nil unless EnableProofCache was called
+	compact bool          // set by EnableCompactStorage
+	stats   map[common.Hash]TrieStats
+	lock    sync.RWMutex
 }
 
 var (
+	errTrieNotFound = errors.New("transaction trie for this transaction root does not exist")
+
 	// from https://github.com/ethereum/go-ethereum/blob/bcb308745010675671991522ad2a9e811938d7fb/trie/trie.go#L32
 	emptyRoot = common.HexToHash("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
 )
@@ -29,7 +36,8 @@ var (
 // NewTxTries creates a new instance of a TxTries object
 func NewTxTries() *TxTries {
 	txTrie := &TxTries{
-		txTries: make(map[common.Hash]*ethtrie.Trie),
+		txTries: make(map[common.Hash]storedTrie),
+		stats:   make(map[common.Hash]TrieStats),
 	}
 	return txTrie
 
@@ -42,74 +50,229 @@ func (t *TxTries) CreateNewTrie(root common.Hash, transactions types.Transaction
 		return errors.New("transactions cannot be nil")
 	}
 
-	trie, err := ethtrie.New(emptyRoot, ethtrie.NewDatabase(nil))
-	if err != nil {
-		return nil
+	return t.createTrie(root, transactions)
+}
+
+// CreateNewReceiptTrie adds a new receipt trie to an existing TxTries object
+func (t *TxTries) CreateNewReceiptTrie(root common.Hash, receipts types.Receipts) error {
+
+	if receipts == nil {
+		return errors.New("receipts cannot be nil")
+	}
+
+	return t.createTrie(root, receipts)
+}
+
+// CreateNewRawTrie adds a new trie of the given values to an existing TxTries object. The values
+// are the consensus encodings stored in the trie, which allows tries of transaction and receipt types
+// this version of go-ethereum can't decode.
+func (t *TxTries) CreateNewRawTrie(root common.Hash, values [][]byte) error {
+
+	if values == nil {
+		return errors.New("values cannot be nil")
 	}
 
-	err = updateTrie(trie, transactions, root)
+	return t.createTrie(root, rawValues(values))
+}
+
+// rawValues is a types.DerivableList of encoded trie values
+type rawValues [][]byte
+
+func (v rawValues) Len() int            { return len(v) }
+func (v rawValues) GetRlp(i int) []byte { return v[i] }
 
+// createTrie builds the trie of list, checks it against root and stores it
+func (t *TxTries) createTrie(root common.Hash, list types.DerivableList) error {
+	_, err := t.storeTrie(root, list, true)
+	return err
+}
+
+// storeTrie builds the trie of list, checks it against root and stores it. A stored trie with the
+// same root is only replaced if replace is set, storeTrie reports whether it added a new root.
+func (t *TxTries) storeTrie(root common.Hash, list types.DerivableList, replace bool) (bool, error) {
+	db := ethtrie.NewDatabase(nil)
+	trie, err := ethtrie.New(emptyRoot, db)
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	t.txRoots = append(t.txRoots, root)
-	t.txTries[root] = trie
+	err = updateTrie(trie, list, root)
 
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	return nil
+	t.lock.RLock()
+	compact := t.compact
+	t.lock.RUnlock()
+
+	var stored storedTrie = trie
+	if compact {
+		stored, err = newCompactTrie(root, trie, db)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	// a root is stored once, adding it again replaces the trie but keeps its position
+	_, exists := t.txTries[root]
+	if !exists {
+		t.txRoots = append(t.txRoots, root)
+	} else if !replace {
+		return false, nil
+	}
+	t.txTries[root] = stored
+
+	return !exists, nil
 }
 
-// updateTrie updates the transaction trie with root transactionRoot with given transactions
-// note that this assumes the slice transactions is in the same order they are in the block
-func updateTrie(trie *ethtrie.Trie, transactions types.Transactions, transactionRoot common.Hash) error {
-	for i, tx := range transactions {
+// DeleteTrie removes the trie with root root from an existing TxTries object
+func (t *TxTries) DeleteTrie(root common.Hash) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
 
-		key, err := rlp.EncodeToBytes(uint(i))
-		if err != nil {
-			return err
+	if _, exists := t.txTries[root]; !exists {
+		return
+	}
+	delete(t.txTries, root)
+	delete(t.stats, root)
+	if t.cache != nil {
+		t.cache.invalidate(root)
+	}
+
+	for i, txRoot := range t.txRoots {
+		if txRoot == root {
+			t.txRoots = append(t.txRoots[:i], t.txRoots[i+1:]...)
+			break
 		}
+	}
+}
+
+// hasTrie checks if a trie with root root is stored
+func (t *TxTries) hasTrie(root common.Hash) bool {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	_, exists := t.txTries[root]
+	return exists
+}
 
-		value, err := rlp.EncodeToBytes(tx)
+// updateTrie updates the trie with root expectedRoot with the given list of transactions or receipts
+// note that this assumes the list is in the same order as in the block
+func updateTrie(trie *ethtrie.Trie, list types.DerivableList, expectedRoot common.Hash) error {
+	for i := 0; i < list.Len(); i++ {
+
+		key, err := rlp.EncodeToBytes(uint(i))
 		if err != nil {
 			return err
 		}
 
-		trie.Update(key, value)
+		trie.Update(key, list.GetRlp(i))
 	}
 
-	// check if the root hash of the trie matches the transactionRoot
-	if trie.Hash().Hex() != transactionRoot.Hex() {
-		return errors.New("transaction roots don't match")
+	// check if the root hash of the trie matches the expectedRoot
+	if trie.Hash().Hex() != expectedRoot.Hex() {
+		return errors.New("trie roots don't match")
 	}
 
 	return nil
 }
 
-// RetrieveEncodedProof retrieves an encoded Proof for a value at key in trie with root root
+// RetrieveEncodedProof retrieves an encoded Proof for a value at key in trie with root root. It returns
+// nil if the trie does not contain key, NestedRLPEncoder also encodes proofs of absence.
 func (t *TxTries) RetrieveEncodedProof(root common.Hash, key []byte) ([]byte, error) {
-	proofDB, err := t.RetrieveProof(root, key)
+	trie, cache := t.lookupTrie(root)
+	if trie == nil {
+		return nil, errTrieNotFound
+	}
+	if cache != nil {
+		if encoded := cache.encodedProof(root, key); encoded != nil {
+			return encoded, nil
+		}
+	}
+
+	// the miss is already counted, so the proof lookup isn't
+	proofDB, err := t.retrieveStoredProof(root, key, trie, cache, false)
+	if err != nil {
+		return nil, err
+	}
+	value, err := verifyProof(root, key, proofDB)
+	if err != nil || value == nil {
+		return nil, err
+	}
+	encoded, err := encodeProofDB(root, key, proofDB)
 	if err != nil {
 		return nil, err
 	}
-	return encodeProofDB(root, key, proofDB)
+
+	if cache != nil {
+		t.storeInCache(root, trie, func() error {
+			cache.storeEncodedProof(root, key, encoded)
+			return nil
+		})
+	}
+	return encoded, nil
 }
 
 // RetrieveProof retrieves a Proof for a value at key in trie with root root
 func (t *TxTries) RetrieveProof(root common.Hash, key []byte) (*ProofDatabase, error) {
-	trieToRetrieve := t.txTries[root]
+	trieToRetrieve, cache := t.lookupTrie(root)
 
 	if trieToRetrieve == nil {
-		return nil, errors.New("transaction trie for this transaction root does not exist")
+		return nil, errTrieNotFound
 	}
 
-	return retrieveProof(trieToRetrieve, key)
+	return t.retrieveStoredProof(root, key, trieToRetrieve, cache, true)
 }
 
-func retrieveProof(trie *ethtrie.Trie, key []byte) (*ProofDatabase, error) {
+// retrieveStoredProof retrieves the proof for key from trie stored at root, through cache if it is not
+// nil. count selects whether the cache lookup is counted in its stats.
+func (t *TxTries) retrieveStoredProof(root common.Hash, key []byte, trie storedTrie, cache *proofCache, count bool) (*ProofDatabase, error) {
+	if cache != nil {
+		if proof := cache.proof(root, key, count); proof != nil {
+			return proof, nil
+		}
+	}
+
+	proof, err := retrieveProof(trie, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if cache != nil {
+		err = t.storeInCache(root, trie, func() error {
+			return cache.storeProof(root, key, proof)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return proof, nil
+}
+
+// lookupTrie returns the trie with root root, or nil, and the proof cache if enabled
+func (t *TxTries) lookupTrie(root common.Hash) (storedTrie, *proofCache) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return t.txTries[root], t.cache
+}
+
+// storeInCache runs store if trie is still stored at root, so proofs of deleted tries are never cached
+func (t *TxTries) storeInCache(root common.Hash, trie storedTrie, store func() error) error {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if trie == nil || t.txTries[root] != trie {
+		return nil
+	}
+	return store()
+}
+
+func retrieveProof(trie storedTrie, key []byte) (*ProofDatabase, error) {
 	var proof = NewProofDatabase()
 	err := trie.Prove(key, 0, proof)
 	if err != nil {
@@ -131,27 +294,5 @@ func VerifyProof(root common.Hash, key []byte, proof *ProofDatabase) (bool, erro
 }
 
 func verifyProof(rootHash common.Hash, key []byte, proofDb ethdb.KeyValueReader) (value []byte, err error) {
-	key = keybytesToHex(key)
-	wantHash := rootHash
-	for i := 0; ; i++ {
-		buf, _ := proofDb.Get(wantHash[:])
-		if buf == nil {
-			return nil, fmt.Errorf("proof node %d (hash %064x) missing", i, wantHash)
-		}
-		n, err := decodeNode(wantHash[:], buf)
-		if err != nil {
-			return nil, fmt.Errorf("bad proof node %d: %v", i, err)
-		}
-		keyrest, cld := get(n, key, true)
-		switch cld := cld.(type) {
-		case nil:
-			// The trie doesn't contain the key.
-			return nil, nil
-		case hashNode:
-			key = keyrest
-			copy(wantHash[:], cld)
-		case valueNode:
-			return cld, nil
-		}
-	}
+	return walkPath(rootHash, key, dbLookup(proofDb), nil)
 }
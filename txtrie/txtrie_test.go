@@ -181,3 +181,58 @@ func TestRetrieveEncodedProof(t *testing.T) {
 		t.Error("unable to rerieve proof")
 	}
 }
+
+func TestRetrieveEncodedProofAbsentKey(t *testing.T) {
+	vals := GetTransactions1()
+	expectedRoot, err := computeEthReferenceTrieHash(vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	txTries := NewTxTries()
+	txTries.EnableProofCache(0)
+	err = addTrie(txTries, expectedRoot, vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := rlp.EncodeToBytes(uint(len(vals)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the second lookup goes through the proof cache
+	for i := 0; i < 2; i++ {
+		encodedProof, err := txTries.RetrieveEncodedProof(expectedRoot, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if encodedProof != nil {
+			t.Fatalf("expected no proof for an absent key, got: %x", encodedProof)
+		}
+	}
+}
+
+func TestAddTrieTwice(t *testing.T) {
+	vals := GetTransactions1()
+	expectedRoot, err := computeEthReferenceTrieHash(vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	txTries := NewTxTries()
+	for i := 0; i < 2; i++ {
+		err = addTrie(txTries, expectedRoot, vals)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(txTries.txRoots) != 1 {
+		t.Fatalf("unexpected number of roots, expected: %d, got: %d", 1, len(txTries.txRoots))
+	}
+
+	txTries.DeleteTrie(expectedRoot)
+	if len(txTries.txRoots) != 0 || txTries.hasTrie(expectedRoot) {
+		t.Fatalf("trie still stored after deletion, roots: %x", txTries.txRoots)
+	}
+}
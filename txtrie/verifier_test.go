@@ -0,0 +1,152 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package txtrie
+
+import (
+	"bytes"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/ChainSafe/chainbridge-ethereum-trie/bindings/txproofverifier"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	ethtrie "github.com/ethereum/go-ethereum/trie"
+)
+
+// proofCase is a proof and the key it is checked against
+type proofCase struct {
+	root         common.Hash
+	key          []byte
+	proofDb      *ProofDatabase
+	encodedProof []byte
+	tampered     bool
+}
+
+// newRandomTxTries creates a TxTries holding a trie of count random values of up to maxValueSize bytes,
+// small values force embedded nodes into the trie
+func newRandomTxTries(t *testing.T, rng *rand.Rand, count, maxValueSize int) (*TxTries, common.Hash) {
+	values := make(rawValues, count)
+	for i := range values {
+		values[i] = make([]byte, rng.Intn(maxValueSize)+1)
+		rng.Read(values[i])
+	}
+
+	root := types.DeriveSha(values, new(ethtrie.Trie))
+	txTries := NewTxTries()
+	if err := txTries.createTrie(root, values); err != nil {
+		t.Fatal(err)
+	}
+	return txTries, root
+}
+
+// generateProofCases returns inclusion cases for every index of random tries, together with
+// failure cases checking each proof against a different key, a wrong root and a tampered encoding
+func generateProofCases(t *testing.T, seed int64) []proofCase {
+	rng := rand.New(rand.NewSource(seed))
+
+	var cases []proofCase
+	for _, count := range []int{1, 2, 16, 17, 128, 129, 300} {
+		for _, maxValueSize := range []int{8, 200} {
+			txTries, root := newRandomTxTries(t, rng, count, maxValueSize)
+
+			for i := 0; i < count; i++ {
+				key, err := rlp.EncodeToBytes(uint(i))
+				if err != nil {
+					t.Fatal(err)
+				}
+				proofDb, err := txTries.RetrieveProof(root, key)
+				if err != nil {
+					t.Fatal(err)
+				}
+				encodedProof, err := txTries.RetrieveEncodedProof(root, key)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				otherKey, err := rlp.EncodeToBytes(uint(rng.Intn(count + 10)))
+				if err != nil {
+					t.Fatal(err)
+				}
+				tampered := common.CopyBytes(encodedProof)
+				tampered[rng.Intn(len(tampered))] ^= byte(rng.Intn(255) + 1)
+
+				cases = append(cases,
+					proofCase{root: root, key: key, proofDb: proofDb, encodedProof: encodedProof},
+					proofCase{root: root, key: otherKey, proofDb: proofDb, encodedProof: encodedProof},
+					proofCase{root: common.Hash{0x01}, key: key, proofDb: proofDb, encodedProof: encodedProof},
+					proofCase{root: root, key: key, proofDb: proofDb, encodedProof: tampered, tampered: true},
+				)
+			}
+		}
+	}
+	return cases
+}
+
+func TestVerifyEncodedProofMatchesVerifyProof(t *testing.T) {
+	for seed := int64(0); seed < 3; seed++ {
+		for _, c := range generateProofCases(t, seed) {
+			encodedValue, encodedErr := verifyEncodedProof(c.root, c.key, c.encodedProof)
+
+			if c.tampered {
+				if encodedErr == nil && encodedValue != nil {
+					t.Fatalf("tampered proof verified, root: %x, key: %x", c.root, c.key)
+				}
+				continue
+			}
+
+			value, err := verifyProof(c.root, c.key, c.proofDb)
+			if (err == nil && value != nil) != (encodedErr == nil && encodedValue != nil) {
+				t.Fatalf("verification results differ, root: %x, key: %x, value: %x (%v), encoded value: %x (%v)",
+					c.root, c.key, value, err, encodedValue, encodedErr)
+			}
+			if !bytes.Equal(value, encodedValue) {
+				t.Fatalf("verified values differ, root: %x, key: %x, expected: %x, got: %x", c.root, c.key, value, encodedValue)
+			}
+		}
+	}
+}
+
+// deployTestVerifier deploys the TxProofVerifier contract on a new simulated backend
+func deployTestVerifier(t *testing.T) (*backends.SimulatedBackend, common.Address, *txproofverifier.TxProofVerifier) {
+	balance := new(big.Int).Mul(big.NewInt(1000), big.NewInt(1e18))
+	backend := backends.NewSimulatedBackend(core.GenesisAlloc{testAddress: {Balance: balance}}, 100000000)
+
+	address, _, verifier, err := txproofverifier.DeployTxProofVerifier(bind.NewKeyedTransactor(testKey), backend)
+	if err != nil {
+		backend.Close()
+		t.Fatal(err)
+	}
+	backend.Commit()
+	return backend, address, verifier
+}
+
+func TestVerifierContractMatchesVerifyProof(t *testing.T) {
+	backend, _, verifier := deployTestVerifier(t)
+	defer backend.Close()
+
+	caller := &txproofverifier.TxProofVerifierCallerRaw{Contract: &verifier.TxProofVerifierCaller}
+
+	for _, c := range generateProofCases(t, 0) {
+		var out []interface{}
+		// reverts are reported as errors and count as failed verifications
+		var onChainValue []byte
+		if err := caller.Call(nil, &out, "verifyProof", c.root, c.key, c.encodedProof); err == nil && out[0].(bool) {
+			onChainValue = out[1].([]byte)
+		}
+
+		value, err := verifyEncodedProof(c.root, c.key, c.encodedProof)
+		if err != nil {
+			value = nil
+		}
+
+		if !bytes.Equal(value, onChainValue) || (value == nil) != (onChainValue == nil) {
+			t.Fatalf("on chain verification differs, root: %x, key: %x, expected: %x, got: %x", c.root, c.key, value, onChainValue)
+		}
+	}
+}
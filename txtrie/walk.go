@@ -0,0 +1,96 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package txtrie
+
+import (
+	"fmt"
+
+	"github.com/ChainSafe/chainbridge-ethereum-trie/txtrie/trienode"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// nodeLookup returns the encoding of the node with hash hash, or nil if it is not known
+type nodeLookup func(hash common.Hash) ([]byte, error)
+
+// dbLookup looks nodes up in db by hash
+func dbLookup(db ethdb.KeyValueReader) nodeLookup {
+	return func(hash common.Hash) ([]byte, error) {
+		buf, _ := db.Get(hash[:])
+		return buf, nil
+	}
+}
+
+// missingNodeError is returned by walkPath when a hashed node on the path can't be looked up
+type missingNodeError struct {
+	index int
+	hash  common.Hash
+}
+
+func (e *missingNodeError) Error() string {
+	return fmt.Sprintf("proof node %d (hash %064x) missing", e.index, e.hash)
+}
+
+// walkedNode is a node reached while following a key
+type walkedNode struct {
+	hash  hashNode // nil for nodes embedded in their parent
+	enc   []byte   // encoding of hashed nodes
+	node  node
+	key   []byte // nibbles of the key left when reaching the node
+	rest  []byte // nibbles of the key left after the node, nil if the key diverges from it
+	child node   // next node on the path, nil if the path ends at the node
+}
+
+// resolveNode looks up and decodes the node with hash hash, it returns a nil node if lookup doesn't know it
+func resolveNode(lookup nodeLookup, hash hashNode) (node, []byte, error) {
+	buf, err := lookup(common.BytesToHash(hash))
+	if err != nil || buf == nil {
+		return nil, nil, err
+	}
+	n, err := decodeNode(hash, buf)
+	if err != nil {
+		return nil, nil, err
+	}
+	return n, buf, nil
+}
+
+// walkPath follows key from root through the nodes returned by lookup and calls visit, if not nil, for
+// every node on the path, embedded ones included. It returns the value at key, or nil if the nodes
+// show the key doesn't exist. Nodes in errors are numbered by their position among the hashed nodes.
+func walkPath(root common.Hash, key []byte, lookup nodeLookup, visit func(*walkedNode) error) ([]byte, error) {
+	nibbles := trienode.KeybytesToHex(key)
+	var tn node = hashNode(root[:])
+	for i := 0; ; {
+		w := &walkedNode{key: nibbles}
+		switch n := tn.(type) {
+		case nil:
+			// the trie doesn't contain the key
+			return nil, nil
+		case valueNode:
+			return n, nil
+		case hashNode:
+			decoded, buf, err := resolveNode(lookup, n)
+			if err != nil {
+				return nil, fmt.Errorf("bad proof node %d: %v", i, err)
+			}
+			if decoded == nil {
+				return nil, &missingNodeError{index: i, hash: common.BytesToHash(n)}
+			}
+			w.hash, w.enc, tn = n, buf, decoded
+			i++
+		}
+
+		rest, child, err := get(tn, nibbles, false)
+		if err != nil {
+			return nil, fmt.Errorf("bad proof node %d: %v", i-1, err)
+		}
+		w.node, w.rest, w.child = tn, rest, child
+		if visit != nil {
+			if err := visit(w); err != nil {
+				return nil, err
+			}
+		}
+		tn, nibbles = child, rest
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2020 ChainSafe Systems
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package txtrie
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+func TestWalkPath(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, count := range []int{1, 2, 16, 17, 129} {
+		txTries, root := newRandomTxTries(t, rng, count, 40)
+
+		// the last key is absent
+		for i := 0; i <= count; i++ {
+			key, err := rlp.EncodeToBytes(uint(i))
+			if err != nil {
+				t.Fatal(err)
+			}
+			proofDb, err := txTries.RetrieveProof(root, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected, err := verifyProof(root, key, proofDb)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			visited := NewProofDatabase()
+			value, err := walkPath(root, key, dbLookup(proofDb), func(w *walkedNode) error {
+				if w.hash == nil {
+					return nil
+				}
+				return visited.Put(w.hash, w.enc)
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(value, expected) || (i < count) != (value != nil) {
+				t.Fatalf("unexpected value of key %x, expected: %x, got: %x", key, expected, value)
+			}
+			if visited.Len() != proofDb.Len() {
+				t.Fatalf("unexpected hashed nodes of key %x, expected: %d, got: %d", key, proofDb.Len(), visited.Len())
+			}
+		}
+	}
+}
+
+func TestWalkPathMissingNode(t *testing.T) {
+	txTries, proofDb, key := retrieveTestProof(t, 1)
+	root := txTries.txRoots[0]
+
+	var last common.Hash
+	if _, err := walkPath(root, key, dbLookup(proofDb), func(w *walkedNode) error {
+		if w.hash != nil {
+			last = common.BytesToHash(w.hash)
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := proofDb.Delete(last[:]); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := walkPath(root, key, dbLookup(proofDb), nil)
+	var missing *missingNodeError
+	if !errors.As(err, &missing) || missing.hash != last || missing.index != proofDb.Len() {
+		t.Fatalf("unexpected error for missing node %x: %v", last, err)
+	}
+}